@@ -1,14 +1,20 @@
 package agent
 
 import (
+	"context"
+	"encoding/json"
 	"log"
 	"sync"
 	"time"
 
 	"aionvanguard/backend/broker"
+	"aionvanguard/backend/config"
 	"aionvanguard/backend/data"
+	"aionvanguard/backend/data/stream"
+	"aionvanguard/backend/persistence"
 	"aionvanguard/backend/risk"
 	"aionvanguard/backend/strategy"
+	"aionvanguard/backend/strategy/xfunding"
 
 	"github.com/alpacahq/alpaca-trade-api-go/v2/alpaca"
 	"github.com/gorilla/websocket"
@@ -17,14 +23,30 @@ import (
 // TradingAgent is the core of the trading bot.
 type TradingAgent struct {
 	Config         *Config
-	Broker         *broker.AlpacaBroker
-	DataFetcher    *data.FinnhubFetcher
-	Strategy       *strategy.CombinedStrategy
+	Broker         broker.Broker
+	DataFetcher    data.Fetcher
+	Strategy       strategy.Strategy
 	RiskManager    *risk.Manager
 	Conn           *websocket.Conn
+	Sessions       map[string]broker.Broker
+	Store          persistence.Store
 	isRunning      bool
+	haltedForSession bool
+	lastResetDate  string
+	xfunding       *xfunding.Strategy
+	// openTrackers marks symbols with a live positionTracker goroutine, set
+	// for the window between PlaceBracketOrder succeeding and the position
+	// actually showing up in Broker.GetOpenPositions(); actOnSignal consults
+	// it alongside GetOpenPositions so a repeated signal on the same bar
+	// can't pyramid a second position into the same symbol.
+	openTrackers   map[string]bool
 	mu             sync.Mutex
-	stopChan       chan struct{}
+	// connMu serializes writes to Conn: gorilla/websocket only supports one
+	// concurrent writer, but sendTick (trading-loop goroutine) and
+	// emitPositionEvent (called from the main loop and from each open
+	// position's own positionTracker goroutine) can all write at once.
+	connMu   sync.Mutex
+	stopChan chan struct{}
 }
 
 // Config holds the configuration for the trading agent.
@@ -33,18 +55,166 @@ type Config struct {
 	RiskPerTrade    float64
 	RiskRewardRatio float64
 	TimeBasedExit   int
+
+	// Symbol, Quantity, FundingRateHigh, FundingRateLow and Leverage
+	// configure the xfunding cross-exchange funding-rate arbitrage
+	// strategy, run across the "spot" and "futures" sessions.
+	Symbol          string
+	Quantity        float64
+	FundingRateHigh float64
+	FundingRateLow  float64
+	Leverage        float64
+
+	// NoNewTradesMinutes blocks new entries within this many minutes of
+	// market close. LiquidationCutoffMinutes flattens all open positions
+	// this many minutes before close and halts further order placement
+	// for the rest of the session. Zero disables either check.
+	NoNewTradesMinutes       int
+	LiquidationCutoffMinutes int
+
+	// StrategyID selects a strategy registered in the strategy package
+	// registry (e.g. "sma_rsi", "mean_reversion"). StrategyConfigPath, if
+	// set, loads the id and params from a YAML file instead, taking
+	// precedence over StrategyID/StrategyParams. If neither is set, the
+	// original SMA+RSI combo is used.
+	StrategyID         string
+	StrategyParams     strategy.Params
+	StrategyConfigPath string
+
+	// PersistenceBackend ("sqlite" or "redis") and PersistenceDSN select
+	// where the agent's daily risk counters and per-strategy state are
+	// snapshotted so a restart can resume without losing them. Leave
+	// PersistenceBackend empty to disable persistence.
+	PersistenceBackend string
+	PersistenceDSN     string
+
+	// TrailPercent ratchets a position's stop by this percentage as price
+	// moves favorably. Zero disables trailing; the bracket order's fixed
+	// stop-loss still applies.
+	TrailPercent float64
 }
 
-// NewTradingAgent creates and configures a new TradingAgent.
+// NewTradingAgent creates and configures a new TradingAgent backed by the
+// live Alpaca broker and Finnhub data fetcher.
 func NewTradingAgent(config *Config, conn *websocket.Conn) *TradingAgent {
+	return NewTradingAgentWithDeps(config, broker.NewAlpacaBroker(), data.NewFinnhubFetcher(), conn)
+}
+
+// NewTradingAgentWithDeps creates a TradingAgent from explicit broker and
+// data fetcher implementations, allowing callers such as the backtest
+// engine to supply simulated dependencies in place of the live Alpaca and
+// Finnhub clients.
+func NewTradingAgentWithDeps(cfg *Config, b broker.Broker, f data.Fetcher, conn *websocket.Conn) *TradingAgent {
+	riskManager := risk.NewManager(10000.0, cfg.RiskPerTrade/100, 0.05)
+	riskManager.NoNewTradesWindow = time.Duration(cfg.NoNewTradesMinutes) * time.Minute
+
+	var store persistence.Store
+	if cfg.PersistenceBackend != "" {
+		var err error
+		store, err = persistence.New(cfg.PersistenceBackend, cfg.PersistenceDSN)
+		if err != nil {
+			log.Printf("Error opening %s persistence store, continuing without it: %v", cfg.PersistenceBackend, err)
+		}
+	}
+
 	return &TradingAgent{
-		Config:      config,
-		Broker:      broker.NewAlpacaBroker(),
-		DataFetcher: data.NewFinnhubFetcher(),
-		Strategy:    strategy.NewCombinedStrategy(20, 50, 14, 70, 30),
-		RiskManager: risk.NewManager(10000.0, config.RiskPerTrade/100, 0.05),
-		Conn:        conn,
-		stopChan:    make(chan struct{}),
+		Config:       cfg,
+		Broker:       b,
+		DataFetcher:  f,
+		Strategy:     resolveStrategy(cfg),
+		RiskManager:  riskManager,
+		Conn:         conn,
+		Sessions:     make(map[string]broker.Broker),
+		Store:        store,
+		stopChan:     make(chan struct{}),
+		openTrackers: make(map[string]bool),
+	}
+}
+
+// resolveStrategy picks the agent's strategy: a YAML config file if
+// StrategyConfigPath is set, otherwise the registered StrategyID, falling
+// back to the original SMA+RSI combo if neither is configured.
+func resolveStrategy(cfg *Config) strategy.Strategy {
+	id, params := cfg.StrategyID, cfg.StrategyParams
+
+	if cfg.StrategyConfigPath != "" {
+		strategyCfg, err := config.LoadStrategyConfig(cfg.StrategyConfigPath)
+		if err != nil {
+			log.Printf("Error loading strategy config %s, falling back to default: %v", cfg.StrategyConfigPath, err)
+		} else {
+			id, params = strategyCfg.ID, strategyCfg.Params
+		}
+	}
+
+	if id == "" {
+		return strategy.NewCombinedStrategy(20, 50, 14, 70, 30)
+	}
+
+	s, err := strategy.New(id, params)
+	if err != nil {
+		log.Printf("Error resolving strategy %q, falling back to default: %v", id, err)
+		return strategy.NewCombinedStrategy(20, 50, 14, 70, 30)
+	}
+	return s
+}
+
+// SetStrategy swaps the agent's active strategy at runtime, e.g. in
+// response to a "configure" WebSocket message.
+func (a *TradingAgent) SetStrategy(s strategy.Strategy) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.Strategy = s
+}
+
+// AddSession registers a named broker session (e.g. "spot" or "futures")
+// for strategies, such as xfunding, that need to hold positions across more
+// than one broker at a time.
+func (a *TradingAgent) AddSession(name string, b broker.Broker) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.Sessions[name] = b
+}
+
+// Session returns the named broker session, if one has been registered.
+func (a *TradingAgent) Session(name string) (broker.Broker, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	b, ok := a.Sessions[name]
+	return b, ok
+}
+
+// evaluateXFunding advances the xfunding cross-exchange funding-rate
+// arbitrage strategy for Config.Symbol by one tick. It is a no-op until
+// both a "spot" and a "futures" broker session have been registered via
+// AddSession, so agents that don't use the funding-rate strategy are
+// unaffected. The strategy is built lazily on first use so AddSession can
+// be called any time before the agent's first trading cycle.
+func (a *TradingAgent) evaluateXFunding() {
+	if a.Config.Symbol == "" {
+		return
+	}
+
+	a.mu.Lock()
+	if a.xfunding == nil {
+		spot, spotOK := a.Sessions["spot"]
+		futures, futuresOK := a.Sessions["futures"]
+		if !spotOK || !futuresOK {
+			a.mu.Unlock()
+			return
+		}
+
+		var store xfunding.Store = xfunding.NewInMemoryStore()
+		if a.Store != nil {
+			store = persistence.NewXFundingStore(a.Store)
+		}
+		a.xfunding = xfunding.NewStrategy(spot, futures, data.NewFundingRateFetcher(), store,
+			a.Config.FundingRateHigh, a.Config.FundingRateLow, a.Config.Leverage)
+	}
+	strat := a.xfunding
+	a.mu.Unlock()
+
+	if err := strat.Evaluate(context.Background(), a.Config.Symbol, a.Config.Quantity); err != nil {
+		log.Printf("xfunding: error evaluating %s: %v", a.Config.Symbol, err)
 	}
 }
 
@@ -57,8 +227,10 @@ func (a *TradingAgent) Start() {
 		return
 	}
 	a.isRunning = true
+	a.haltedForSession = false
 	a.mu.Unlock()
 
+	a.Load()
 	go a.runTradingLoop()
 }
 
@@ -75,8 +247,145 @@ func (a *TradingAgent) Stop() {
 	a.mu.Unlock()
 }
 
+// strategyStateKey is the StrategyState key a StatefulStrategy's per-symbol
+// position state is nested under.
+const strategyStateKey = "strategy"
+
+// Load restores the daily risk counters and per-strategy state from the
+// configured Store, if one is set. It is a no-op when persistence is
+// disabled.
+func (a *TradingAgent) Load() {
+	if a.Store == nil {
+		return
+	}
+
+	state, err := a.Store.Load(context.Background())
+	if err != nil {
+		log.Println("Error loading persisted agent state:", err)
+		return
+	}
+
+	a.mu.Lock()
+	a.RiskManager.DailyLossIncurred = state.DailyLossIncurred
+	a.lastResetDate = state.LastResetDate
+	stateful, ok := a.Strategy.(strategy.StatefulStrategy)
+	a.mu.Unlock()
+
+	if ok {
+		if raw, found := state.StrategyState[strategyStateKey]; found {
+			var symbolState map[string]strategy.Signal
+			if err := json.Unmarshal(raw, &symbolState); err != nil {
+				log.Println("Error decoding persisted strategy state:", err)
+			} else {
+				stateful.RestoreState(a.reconcileStrategyState(symbolState))
+			}
+		}
+	}
+	log.Println("persistence: restored agent state from store")
+}
+
+// reconcileStrategyState drops a persisted Buy/Sell entry whenever the
+// broker no longer shows a matching open position for that symbol, so a
+// bracket order whose stop-loss/take-profit filled while the agent was down
+// isn't mistaken on restart for a position that still needs closing (which
+// would also suppress a genuine new entry in the same direction).
+func (a *TradingAgent) reconcileStrategyState(symbolState map[string]strategy.Signal) map[string]strategy.Signal {
+	positions, err := a.Broker.GetOpenPositions()
+	if err != nil {
+		log.Println("Error reconciling persisted strategy state against open positions:", err)
+		return symbolState
+	}
+
+	open := make(map[string]strategy.Signal, len(positions))
+	for _, pos := range positions {
+		qty, _ := pos.Qty.Float64()
+		switch {
+		case qty > 0:
+			open[pos.Symbol] = strategy.Buy
+		case qty < 0:
+			open[pos.Symbol] = strategy.Sell
+		}
+	}
+
+	reconciled := make(map[string]strategy.Signal, len(symbolState))
+	for symbol, signal := range symbolState {
+		if signal == strategy.Hold || open[symbol] == signal {
+			reconciled[symbol] = signal
+		}
+	}
+	return reconciled
+}
+
+// Save snapshots the daily risk counters and, if the active strategy is a
+// strategy.StatefulStrategy, its per-symbol position state, to the
+// configured Store, if one is set. It is called after each trade cycle so
+// a restart picks up where the agent left off.
+func (a *TradingAgent) Save() {
+	if a.Store == nil {
+		return
+	}
+
+	a.mu.Lock()
+	dailyLoss := a.RiskManager.DailyLossIncurred
+	lastReset := a.lastResetDate
+	stateful, ok := a.Strategy.(strategy.StatefulStrategy)
+	a.mu.Unlock()
+
+	state, err := a.Store.Load(context.Background())
+	if err != nil {
+		log.Println("Error loading persisted agent state before save:", err)
+		return
+	}
+	state.DailyLossIncurred = dailyLoss
+	state.LastResetDate = lastReset
+
+	if ok {
+		raw, err := json.Marshal(stateful.State())
+		if err != nil {
+			log.Println("Error encoding strategy state:", err)
+		} else {
+			if state.StrategyState == nil {
+				state.StrategyState = make(map[string]json.RawMessage)
+			}
+			state.StrategyState[strategyStateKey] = raw
+		}
+	}
+
+	if err := a.Store.Save(context.Background(), state); err != nil {
+		log.Println("Error saving agent state:", err)
+	}
+}
+
+// runTradingLoop drives signal generation either from a live Alpaca bar
+// stream, dispatching a.onBarClose on every bar close, or — when streaming
+// credentials aren't configured — from the original 60-second polling
+// loop.
 func (a *TradingAgent) runTradingLoop() {
-	log.Println("Trading loop started.")
+	if !stream.Available() {
+		log.Println("Streaming credentials not configured, falling back to polling.")
+		a.runPollingLoop()
+		return
+	}
+
+	log.Println("Trading loop started (streaming).")
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-a.stopChan
+		cancel()
+	}()
+
+	client := stream.NewStocksClient(a.Config.Symbols, a.Strategy.WarmupPeriod()+1, a.onBarClose)
+	if err := client.Run(ctx); err != nil && ctx.Err() == nil {
+		log.Printf("Streaming loop exited with error, falling back to polling: %v", err)
+		a.runPollingLoop()
+		return
+	}
+	log.Println("Trading loop stopped.")
+}
+
+// runPollingLoop is the original wall-clock trading loop, used when
+// streaming market data isn't available.
+func (a *TradingAgent) runPollingLoop() {
 	ticker := time.NewTicker(60 * time.Second)
 	defer ticker.Stop()
 
@@ -91,7 +400,59 @@ func (a *TradingAgent) runTradingLoop() {
 	}
 }
 
+// onBarClose is invoked by the streaming client whenever a new bar closes
+// for a symbol. It generates a signal from the rolling window and forwards
+// the tick to the frontend WebSocket so the UI can render a live chart.
+func (a *TradingAgent) onBarClose(symbol string, closes []float64) {
+	a.sendTick(symbol, closes[len(closes)-1])
+
+	if a.marketCloseGuard() {
+		return
+	}
+
+	account, err := a.Broker.GetAccount()
+	if err != nil {
+		log.Println("Error getting account:", err)
+		return
+	}
+	balance, _ := account.Equity.Float64()
+	a.RiskManager.UpdateAccountBalance(balance)
+
+	a.actOnSignal(symbol, closes)
+	a.evaluateXFunding()
+	a.Save()
+}
+
+// sendTick forwards a tick-level price update to the connected frontend
+// client, if any.
+func (a *TradingAgent) sendTick(symbol string, price float64) {
+	if a.Conn == nil {
+		return
+	}
+	payload, err := json.Marshal(map[string]interface{}{
+		"symbol": symbol,
+		"price":  price,
+		"time":   time.Now(),
+	})
+	if err != nil {
+		log.Println("Error marshalling tick payload:", err)
+		return
+	}
+	a.connMu.Lock()
+	defer a.connMu.Unlock()
+	if err := a.Conn.WriteJSON(struct {
+		Type    string          `json:"type"`
+		Payload json.RawMessage `json:"payload"`
+	}{Type: "tick", Payload: payload}); err != nil {
+		log.Println("Error writing tick to WebSocket:", err)
+	}
+}
+
 func (a *TradingAgent) trade() {
+	if a.marketCloseGuard() {
+		return
+	}
+
 	account, err := a.Broker.GetAccount()
 	if err != nil {
 		log.Println("Error getting account:", err)
@@ -103,6 +464,131 @@ func (a *TradingAgent) trade() {
 	for _, symbol := range a.Config.Symbols {
 		a.processSymbol(symbol)
 	}
+	a.evaluateXFunding()
+	a.Save()
+}
+
+// marketCloseGuard checks the market clock and enforces end-of-day
+// liquidation: it flattens all open positions and halts further order
+// placement for the rest of the session once within
+// Config.LiquidationCutoffMinutes of close, and blocks new entries within
+// Config.NoNewTradesMinutes of close or once the day's realized losses
+// breach RiskManager.DailyRiskLimitPercentage. It returns true if the
+// caller should skip this cycle entirely.
+func (a *TradingAgent) marketCloseGuard() bool {
+	clock, err := a.Broker.GetClock()
+	if err != nil {
+		log.Println("Error getting market clock:", err)
+		a.mu.Lock()
+		halted := a.haltedForSession
+		a.mu.Unlock()
+		return halted
+	}
+
+	// Check the clock and clear a stale halt before looking at the halt
+	// flag, so a session boundary is detected even while halted.
+	a.resetDailyRiskOnNewSession(clock)
+
+	a.mu.Lock()
+	halted := a.haltedForSession
+	a.mu.Unlock()
+	if halted {
+		return true
+	}
+
+	if !clock.IsOpen || clock.NextClose.IsZero() {
+		return false
+	}
+
+	now := time.Now()
+	cutoff := time.Duration(a.Config.LiquidationCutoffMinutes) * time.Minute
+	if cutoff > 0 && !now.Before(clock.NextClose.Add(-cutoff)) {
+		a.liquidateAll()
+		return true
+	}
+
+	return a.RiskManager.ShouldStopOpening(now, clock.NextClose) || a.dailyLossLimitExceeded()
+}
+
+// resetDailyRiskOnNewSession zeroes the daily loss counter once per
+// exchange-calendar trading session, rather than at wall-clock midnight,
+// so the reset lines up with when the market actually opens. This is also
+// the session boundary at which an EOD halt from liquidateAll clears,
+// since both represent "a new trading session has begun".
+func (a *TradingAgent) resetDailyRiskOnNewSession(clock *alpaca.Clock) {
+	if !clock.IsOpen {
+		return
+	}
+
+	sessionDate := clock.Timestamp.Format("2006-01-02")
+
+	a.mu.Lock()
+	alreadyReset := a.lastResetDate == sessionDate
+	if !alreadyReset {
+		a.lastResetDate = sessionDate
+		a.haltedForSession = false
+		a.RiskManager.DailyLossIncurred = 0
+	}
+	a.mu.Unlock()
+
+	if alreadyReset {
+		return
+	}
+
+	log.Println("risk: daily loss counter reset for trading session", sessionDate)
+}
+
+// recordTradePnL folds a closed trade's realized PnL into the daily risk
+// budget under a.mu, since RiskManager.DailyLossIncurred is also read and
+// written by Save/Load/resetDailyRiskOnNewSession and can be touched from
+// more than one positionTracker goroutine at once.
+func (a *TradingAgent) recordTradePnL(pnl float64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.RiskManager.RecordTradePnL(pnl)
+}
+
+// dailyLossLimitExceeded reports RiskManager.DailyLossLimitExceeded() under
+// a.mu, for the same reason recordTradePnL takes the lock.
+func (a *TradingAgent) dailyLossLimitExceeded() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.RiskManager.DailyLossLimitExceeded()
+}
+
+// liquidateAll flattens every open position and halts further order
+// placement for the remainder of the session.
+func (a *TradingAgent) liquidateAll() {
+	a.mu.Lock()
+	a.haltedForSession = true
+	a.mu.Unlock()
+
+	positions, err := a.Broker.GetOpenPositions()
+	if err != nil {
+		log.Println("Error getting open positions for liquidation:", err)
+		return
+	}
+
+	for _, pos := range positions {
+		qty, _ := pos.Qty.Float64()
+		side := alpaca.Sell
+		if qty < 0 {
+			side = alpaca.Buy
+			qty = -qty
+		}
+		if _, err := a.Broker.PlaceOrder(pos.Symbol, qty, side, alpaca.Market, alpaca.GTC); err != nil {
+			log.Printf("Error liquidating position in %s: %v", pos.Symbol, err)
+			continue
+		}
+
+		// Flattening with a plain market order doesn't touch the position's
+		// bracket legs, so cancel them or a stale stop-loss/take-profit can
+		// later fill against zero position and open a fresh, unintended one.
+		if err := a.Broker.CancelOpenOrders(pos.Symbol); err != nil {
+			log.Printf("Error cancelling resting orders for %s: %v", pos.Symbol, err)
+		}
+	}
+	log.Println("Liquidated all open positions ahead of market close; no further orders will be placed this session.")
 }
 
 func (a *TradingAgent) processSymbol(symbol string) {
@@ -116,16 +602,40 @@ func (a *TradingAgent) processSymbol(symbol string) {
 		return
 	}
 
-	signal := a.Strategy.GenerateSignal(candles.C)
+	rawCloses := candles.GetC()
+	closes := make([]float64, len(rawCloses))
+	for i, c := range rawCloses {
+		closes[i] = float64(c)
+	}
+
+	a.actOnSignal(symbol, closes)
+}
+
+// actOnSignal generates a signal from closes and, if actionable, sizes and
+// places a bracket order with an attached stop-loss/take-profit pair. It is
+// shared by the polling path (closes fetched from history) and the
+// streaming path (closes from the rolling bar window).
+func (a *TradingAgent) actOnSignal(symbol string, closes []float64) {
+	signal := a.Strategy.GenerateSignal(symbol, closes)
 	if signal == strategy.Hold {
 		return
 	}
 
-	entryPrice := candles.C[len(candles.C)-1]
+	hasPosition, err := a.hasOpenPositionOrTracker(symbol)
+	if err != nil {
+		log.Printf("Error checking open positions for %s: %v", symbol, err)
+		return
+	}
+	if hasPosition {
+		return
+	}
+
+	entryPrice := closes[len(closes)-1]
 	stopLossPrice := entryPrice * (1 - 0.02)
 	if signal == strategy.Sell {
 		stopLossPrice = entryPrice * (1 + 0.02)
 	}
+	takeProfitPrice := a.RiskManager.DetermineTakeProfit(entryPrice, stopLossPrice, a.Config.RiskRewardRatio)
 
 	positionSize := a.RiskManager.CalculatePositionSize(entryPrice, stopLossPrice)
 	if positionSize > 0 {
@@ -134,9 +644,241 @@ func (a *TradingAgent) processSymbol(symbol string) {
 			side = alpaca.Sell
 		}
 
-		_, err := a.Broker.PlaceOrder(symbol, positionSize, side, alpaca.Market, alpaca.GTC)
+		_, err := a.Broker.PlaceBracketOrder(symbol, positionSize, side, 0, stopLossPrice, takeProfitPrice)
 		if err != nil {
-			log.Printf("Error placing order for %s: %v", symbol, err)
+			log.Printf("Error placing bracket order for %s: %v", symbol, err)
+			return
+		}
+		a.emitPositionEvent(symbol, "opened", entryPrice)
+		a.setOpenTracker(symbol, true)
+		go a.trackPosition(symbol, side, positionSize, entryPrice, stopLossPrice, takeProfitPrice)
+	}
+}
+
+// hasOpenPositionOrTracker reports whether symbol already has an open
+// broker position or a live positionTracker goroutine. A repeated signal
+// for a symbol that's already been acted on must not size and place another
+// bracket order: the new tracker would race the existing one on forceClose
+// and trailing-stop logic and double-count PnL on close.
+func (a *TradingAgent) hasOpenPositionOrTracker(symbol string) (bool, error) {
+	a.mu.Lock()
+	tracked := a.openTrackers[symbol]
+	a.mu.Unlock()
+	if tracked {
+		return true, nil
+	}
+
+	positions, err := a.Broker.GetOpenPositions()
+	if err != nil {
+		return false, err
+	}
+	for _, pos := range positions {
+		if pos.Symbol == symbol {
+			return true, nil
 		}
 	}
+	return false, nil
+}
+
+// setOpenTracker marks or clears symbol's live-tracker flag, consulted by
+// hasOpenPositionOrTracker.
+func (a *TradingAgent) setOpenTracker(symbol string, tracked bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if tracked {
+		a.openTrackers[symbol] = true
+	} else {
+		delete(a.openTrackers, symbol)
+	}
+}
+
+// emitPositionEvent forwards a position lifecycle update (opened, trailed,
+// closed:<reason>) to the connected frontend client, if any.
+func (a *TradingAgent) emitPositionEvent(symbol, event string, price float64) {
+	if a.Conn == nil {
+		return
+	}
+	payload, err := json.Marshal(map[string]interface{}{
+		"symbol": symbol,
+		"event":  event,
+		"price":  price,
+		"time":   time.Now(),
+	})
+	if err != nil {
+		log.Println("Error marshalling position event payload:", err)
+		return
+	}
+	a.connMu.Lock()
+	defer a.connMu.Unlock()
+	if err := a.Conn.WriteJSON(struct {
+		Type    string          `json:"type"`
+		Payload json.RawMessage `json:"payload"`
+	}{Type: "position", Payload: payload}); err != nil {
+		log.Println("Error writing position event to WebSocket:", err)
+	}
+}
+
+// positionTracker manages the client-side lifecycle of a single bracket
+// order after it's placed: ratcheting a trailing stop as price moves
+// favorably, and forcing an exit once Config.TimeBasedExit elapses. The
+// bracket's own stop-loss/take-profit legs are managed by Alpaca as an OCO
+// pair, so the tracker only needs to detect when they've resolved the
+// position and stop polling.
+type positionTracker struct {
+	agent       *TradingAgent
+	symbol      string
+	side        alpaca.Side
+	qty         float64
+	entryPrice  float64
+	stopPrice   float64
+	targetPrice float64
+	openedAt    time.Time
+
+	// lastSeenPrice is the most recent price isStillOpen reported while the
+	// position was open, used to estimate the exit price for PnL purposes
+	// when the bracket's own OCO legs resolve the position (no exit price
+	// is available once the broker no longer reports an open position).
+	lastSeenPrice float64
+}
+
+// trackPosition starts a positionTracker for a newly opened bracket order.
+// It runs until the position closes (bracket fill, forced time-based exit,
+// or trailing stop) and is intended to be run in its own goroutine.
+func (a *TradingAgent) trackPosition(symbol string, side alpaca.Side, qty, entryPrice, stopPrice, targetPrice float64) {
+	t := &positionTracker{
+		agent:         a,
+		symbol:        symbol,
+		side:          side,
+		qty:           qty,
+		entryPrice:    entryPrice,
+		stopPrice:     stopPrice,
+		targetPrice:   targetPrice,
+		openedAt:      time.Now(),
+		lastSeenPrice: entryPrice,
+	}
+	t.run()
+}
+
+// run polls the position every 30 seconds until it's no longer open,
+// ratcheting the trailing stop and enforcing the time-based exit along the
+// way. It also stops as soon as the agent is told to stop, rather than
+// continuing to poll and place orders against a broker the agent has
+// otherwise quit talking to.
+func (t *positionTracker) run() {
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+	defer t.agent.setOpenTracker(t.symbol, false)
+
+	for {
+		select {
+		case <-t.agent.stopChan:
+			return
+		case <-ticker.C:
+		}
+
+		open, lastPrice, err := t.isStillOpen()
+		if err != nil {
+			log.Printf("positionTracker: error checking %s position, will retry: %v", t.symbol, err)
+			continue
+		}
+		if !open {
+			// The bracket's own OCO legs resolved the position server-side,
+			// so there's no exit price to read back; estimate the PnL from
+			// the last price observed while it was still open.
+			t.recordClosePnL(t.lastSeenPrice)
+			t.agent.emitPositionEvent(t.symbol, "closed:bracket", t.lastSeenPrice)
+			return
+		}
+		t.lastSeenPrice = lastPrice
+
+		if t.agent.Config.TimeBasedExit > 0 && time.Since(t.openedAt) >= time.Duration(t.agent.Config.TimeBasedExit)*time.Minute {
+			t.forceClose("time_exit", lastPrice)
+			return
+		}
+
+		if t.agent.Config.TrailPercent > 0 {
+			t.ratchetTrailingStop(lastPrice)
+		}
+	}
+}
+
+// isStillOpen reports whether the broker still shows an open position in
+// this symbol, along with the position's current price if so.
+func (t *positionTracker) isStillOpen() (bool, float64, error) {
+	positions, err := t.agent.Broker.GetOpenPositions()
+	if err != nil {
+		return false, 0, err
+	}
+	for _, pos := range positions {
+		if pos.Symbol != t.symbol {
+			continue
+		}
+		price := t.entryPrice
+		if pos.CurrentPrice != nil {
+			price, _ = pos.CurrentPrice.Float64()
+		}
+		return true, price, nil
+	}
+	return false, 0, nil
+}
+
+// ratchetTrailingStop tightens the stop toward the current price once price
+// has moved far enough in the position's favor, logging the new level. The
+// bracket's server-side stop isn't modified here, since the Alpaca client
+// used by this broker has no order-amend call; the tightened stop is
+// enforced by forceClose once price retraces past it.
+func (t *positionTracker) ratchetTrailingStop(lastPrice float64) {
+	trail := t.agent.Config.TrailPercent / 100
+	if t.side == alpaca.Buy {
+		candidate := lastPrice * (1 - trail)
+		if candidate > t.stopPrice {
+			t.stopPrice = candidate
+			t.agent.emitPositionEvent(t.symbol, "trailed", t.stopPrice)
+		} else if lastPrice <= t.stopPrice {
+			t.forceClose("trailing_stop", lastPrice)
+		}
+		return
+	}
+
+	candidate := lastPrice * (1 + trail)
+	if candidate < t.stopPrice {
+		t.stopPrice = candidate
+		t.agent.emitPositionEvent(t.symbol, "trailed", t.stopPrice)
+	} else if lastPrice >= t.stopPrice {
+		t.forceClose("trailing_stop", lastPrice)
+	}
+}
+
+// forceClose flattens the position with an opposite-side market order,
+// records the realized PnL against the daily risk budget, and emits a
+// "closed:<reason>" lifecycle event.
+func (t *positionTracker) forceClose(reason string, lastPrice float64) {
+	closingSide := alpaca.Sell
+	if t.side == alpaca.Sell {
+		closingSide = alpaca.Buy
+	}
+	if _, err := t.agent.Broker.PlaceOrder(t.symbol, t.qty, closingSide, alpaca.Market, alpaca.GTC); err != nil {
+		log.Printf("positionTracker: error force-closing %s position (%s): %v", t.symbol, reason, err)
+		return
+	}
+
+	// The bracket's own stop-loss/take-profit legs are still resting at the
+	// broker; cancel them now that the position is flat, or a stale leg can
+	// later fill against zero position and open a fresh, unintended one.
+	if err := t.agent.Broker.CancelOpenOrders(t.symbol); err != nil {
+		log.Printf("positionTracker: error cancelling resting orders for %s: %v", t.symbol, err)
+	}
+
+	t.recordClosePnL(lastPrice)
+	t.agent.emitPositionEvent(t.symbol, "closed:"+reason, lastPrice)
+}
+
+// recordClosePnL folds this position's realized PnL at the given exit price
+// into the agent's daily risk budget.
+func (t *positionTracker) recordClosePnL(exitPrice float64) {
+	pnl := (exitPrice - t.entryPrice) * t.qty
+	if t.side == alpaca.Sell {
+		pnl = (t.entryPrice - exitPrice) * t.qty
+	}
+	t.agent.recordTradePnL(pnl)
 }
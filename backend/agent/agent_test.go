@@ -0,0 +1,257 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"aionvanguard/backend/broker"
+	"aionvanguard/backend/risk"
+	"aionvanguard/backend/strategy"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v2/alpaca"
+	"github.com/shopspring/decimal"
+)
+
+// fakeBroker is a minimal broker.Broker whose clock is settable, to drive
+// marketCloseGuard through session boundaries without a live connection.
+type fakeBroker struct {
+	clock              alpaca.Clock
+	placeOrders        int
+	placeBracketOrders int
+	openPosition       *alpaca.Position
+	cancelSymbols      []string
+}
+
+var _ broker.Broker = (*fakeBroker)(nil)
+
+func (f *fakeBroker) PlaceOrder(symbol string, qty float64, side alpaca.Side, orderType alpaca.OrderType, timeInForce alpaca.TimeInForce) (string, error) {
+	f.placeOrders++
+	return "order-1", nil
+}
+
+func (f *fakeBroker) PlaceBracketOrder(symbol string, qty float64, side alpaca.Side, entry, stop, target float64) (string, error) {
+	f.placeBracketOrders++
+	return "order-1", nil
+}
+
+func (f *fakeBroker) CancelOpenOrders(symbol string) error {
+	f.cancelSymbols = append(f.cancelSymbols, symbol)
+	return nil
+}
+
+func (f *fakeBroker) GetAccount() (*alpaca.Account, error) { return &alpaca.Account{}, nil }
+
+func (f *fakeBroker) GetOpenPositions() ([]alpaca.Position, error) {
+	if f.openPosition == nil {
+		return nil, nil
+	}
+	return []alpaca.Position{*f.openPosition}, nil
+}
+
+func (f *fakeBroker) GetClock() (*alpaca.Clock, error) {
+	clock := f.clock
+	return &clock, nil
+}
+
+// TestMarketCloseGuardClearsHaltOnNewSession guards against the EOD halt
+// becoming permanent: once liquidateAll trips haltedForSession, the agent
+// must resume placing orders on the next trading session rather than
+// staying halted for every session thereafter.
+func TestMarketCloseGuardClearsHaltOnNewSession(t *testing.T) {
+	// marketCloseGuard compares the real wall clock against clock.NextClose,
+	// so the fixture's close times must be anchored to time.Now(), not a
+	// fixed historical date.
+	now := time.Now()
+	day1 := now.Add(-5 * time.Minute)
+	day1Close := now.Add(5 * time.Minute)
+
+	b := &fakeBroker{clock: alpaca.Clock{
+		Timestamp: day1,
+		IsOpen:    true,
+		NextClose: day1Close,
+	}}
+
+	a := &TradingAgent{
+		Broker:      b,
+		RiskManager: risk.NewManager(10000, 0.01, 0.02),
+		Config:      &Config{LiquidationCutoffMinutes: 10},
+	}
+
+	if !a.marketCloseGuard() {
+		t.Fatal("marketCloseGuard: expected true within the liquidation cutoff window")
+	}
+	a.mu.Lock()
+	halted := a.haltedForSession
+	a.mu.Unlock()
+	if !halted {
+		t.Fatal("haltedForSession: expected true after liquidateAll ran")
+	}
+
+	// Same session: still halted.
+	if !a.marketCloseGuard() {
+		t.Fatal("marketCloseGuard: expected to stay halted later in the same session")
+	}
+
+	// Next trading session: the halt must clear. Its close is far enough
+	// out to be outside the liquidation cutoff window.
+	day2 := day1.AddDate(0, 0, 1)
+	day2Close := day1Close.AddDate(0, 0, 1)
+	b.clock = alpaca.Clock{Timestamp: day2, IsOpen: true, NextClose: day2Close}
+
+	if a.marketCloseGuard() {
+		t.Fatal("marketCloseGuard: expected false at the start of a new session")
+	}
+	a.mu.Lock()
+	halted = a.haltedForSession
+	a.mu.Unlock()
+	if halted {
+		t.Fatal("haltedForSession: expected the halt to clear at the new session boundary")
+	}
+}
+
+// TestPositionTrackerStopsOnAgentStop guards against a positionTracker
+// outliving TradingAgent.Stop(): the tracker's 30s ticker loop must also
+// select on the agent's stopChan, or it keeps polling and placing orders
+// against the broker after the agent has otherwise shut down.
+func TestPositionTrackerStopsOnAgentStop(t *testing.T) {
+	b := &fakeBroker{openPosition: &alpaca.Position{Symbol: "AAPL"}}
+	a := &TradingAgent{
+		Broker:   b,
+		Config:   &Config{},
+		stopChan: make(chan struct{}),
+	}
+	close(a.stopChan)
+
+	tr := &positionTracker{agent: a, symbol: "AAPL", openedAt: time.Now()}
+
+	done := make(chan struct{})
+	go func() {
+		tr.run()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("positionTracker.run did not return promptly after the agent stopped")
+	}
+}
+
+// TestMarketCloseGuardBlocksOnDailyLossLimit guards against the daily risk
+// budget being tracked but never enforced: once RecordTradePnL pushes
+// DailyLossIncurred past DailyRiskLimitPercentage, marketCloseGuard must
+// stop the agent from opening further trades for the rest of the session.
+func TestMarketCloseGuardBlocksOnDailyLossLimit(t *testing.T) {
+	now := time.Now()
+	b := &fakeBroker{clock: alpaca.Clock{
+		Timestamp: now,
+		IsOpen:    true,
+		NextClose: now.Add(2 * time.Hour),
+	}}
+
+	rm := risk.NewManager(10000, 0.01, 0.02) // 2% of 10000 = 200
+	a := &TradingAgent{
+		Broker:      b,
+		RiskManager: rm,
+		Config:      &Config{LiquidationCutoffMinutes: 10},
+	}
+
+	if a.marketCloseGuard() {
+		t.Fatal("marketCloseGuard: expected false before any losses are recorded")
+	}
+
+	rm.RecordTradePnL(-250)
+
+	if !a.marketCloseGuard() {
+		t.Fatal("marketCloseGuard: expected true once the daily loss limit is breached")
+	}
+}
+
+// TestForceCloseCancelsRestingOrders guards against forceClose flattening a
+// position with an offsetting market order but leaving the bracket's own
+// stop-loss/take-profit legs resting at the broker, where they could later
+// fill against zero position and open a fresh, unintended one.
+func TestForceCloseCancelsRestingOrders(t *testing.T) {
+	b := &fakeBroker{}
+	a := &TradingAgent{Broker: b, RiskManager: risk.NewManager(10000, 0.01, 0.02)}
+	tr := &positionTracker{agent: a, symbol: "AAPL", side: alpaca.Buy, qty: 10, entryPrice: 100}
+
+	tr.forceClose("time_exit", 95)
+
+	if len(b.cancelSymbols) != 1 || b.cancelSymbols[0] != "AAPL" {
+		t.Fatalf("cancelSymbols = %v, want [\"AAPL\"]", b.cancelSymbols)
+	}
+}
+
+// TestLiquidateAllCancelsRestingOrders guards against the same gap in the
+// EOD liquidation path: liquidateAll must cancel each symbol's resting
+// bracket legs after flattening it with a market order.
+func TestLiquidateAllCancelsRestingOrders(t *testing.T) {
+	b := &fakeBroker{openPosition: &alpaca.Position{Symbol: "AAPL", Qty: decimal.NewFromFloat(10)}}
+	a := &TradingAgent{Broker: b}
+
+	a.liquidateAll()
+
+	if len(b.cancelSymbols) != 1 || b.cancelSymbols[0] != "AAPL" {
+		t.Fatalf("cancelSymbols = %v, want [\"AAPL\"]", b.cancelSymbols)
+	}
+}
+
+// TestActOnSignalDoesNotPyramid guards against a strategy repeating the
+// same signal across consecutive bars opening a second bracket order (and a
+// second positionTracker goroutine) for a symbol that already has one.
+func TestActOnSignalDoesNotPyramid(t *testing.T) {
+	b := &fakeBroker{openPosition: &alpaca.Position{Symbol: "AAPL", Qty: decimal.NewFromFloat(10)}}
+	a := &TradingAgent{
+		Broker:       b,
+		RiskManager:  risk.NewManager(10000, 0.01, 0.02),
+		Config:       &Config{RiskRewardRatio: 2},
+		Strategy:     alwaysBuy{},
+		openTrackers: make(map[string]bool),
+	}
+
+	a.actOnSignal("AAPL", []float64{100, 101, 102})
+
+	if b.placeBracketOrders != 0 {
+		t.Fatalf("PlaceBracketOrder called %d times, want 0 (existing open position must block a new entry)", b.placeBracketOrders)
+	}
+}
+
+// alwaysBuy is a strategy.Strategy stub that always signals Buy, used to
+// exercise actOnSignal's entry guard without depending on a real strategy's
+// indicator math.
+type alwaysBuy struct{}
+
+func (alwaysBuy) GenerateSignal(symbol string, closes []float64) strategy.Signal {
+	return strategy.Buy
+}
+
+func (alwaysBuy) WarmupPeriod() int { return 0 }
+
+// TestPositionTrackerRecordClosePnL guards against the daily loss limit
+// only ever seeing PnL from forceClose (time-based exit, trailing stop):
+// recordClosePnL is also the path run() takes when the bracket's own
+// stop-loss/take-profit legs resolve the position, using the last price
+// observed before the position disappeared as the exit price estimate.
+func TestPositionTrackerRecordClosePnL(t *testing.T) {
+	rm := risk.NewManager(10000, 0.01, 0.02)
+	a := &TradingAgent{RiskManager: rm}
+
+	long := &positionTracker{agent: a, side: alpaca.Buy, qty: 10, entryPrice: 100}
+	long.recordClosePnL(90)
+	if rm.DailyLossIncurred != 100 {
+		t.Fatalf("DailyLossIncurred = %f, want 100 after a losing long close", rm.DailyLossIncurred)
+	}
+
+	short := &positionTracker{agent: a, side: alpaca.Sell, qty: 5, entryPrice: 50}
+	short.recordClosePnL(60)
+	if rm.DailyLossIncurred != 150 {
+		t.Fatalf("DailyLossIncurred = %f, want 150 after a losing short close", rm.DailyLossIncurred)
+	}
+
+	winner := &positionTracker{agent: a, side: alpaca.Buy, qty: 10, entryPrice: 100}
+	winner.recordClosePnL(110)
+	if rm.DailyLossIncurred != 150 {
+		t.Fatalf("DailyLossIncurred = %f, want unchanged 150 after a winning close", rm.DailyLossIncurred)
+	}
+}
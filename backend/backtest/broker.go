@@ -0,0 +1,148 @@
+package backtest
+
+import (
+	"fmt"
+	"log"
+
+	"aionvanguard/backend/broker"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v2/alpaca"
+	"github.com/shopspring/decimal"
+)
+
+// position tracks a single open simulated position.
+type position struct {
+	side       alpaca.Side
+	qty        float64
+	entryPrice float64
+}
+
+// Fill records the simulated execution of an order, used to build the
+// final trade ledger once the replay completes.
+type Fill struct {
+	Symbol     string
+	Side       alpaca.Side
+	Qty        float64
+	EntryPrice float64
+	ExitPrice  float64
+	PnL        float64
+}
+
+// MockBroker simulates order execution against the last price seen for
+// each symbol, implementing the same interface as broker.AlpacaBroker so
+// the backtest engine can drive agent.TradingAgent without touching a live
+// account.
+type MockBroker struct {
+	equity    float64
+	prices    map[string]float64
+	positions map[string]*position
+	fills     []Fill
+	nextOrder int
+}
+
+var _ broker.Broker = (*MockBroker)(nil)
+
+// NewMockBroker creates a simulated broker seeded with the given starting
+// equity.
+func NewMockBroker(startingEquity float64) *MockBroker {
+	return &MockBroker{
+		equity:    startingEquity,
+		prices:    make(map[string]float64),
+		positions: make(map[string]*position),
+	}
+}
+
+// SetPrice updates the last known price for a symbol, driving mark-to-market
+// equity and the fill price for any subsequent order.
+func (m *MockBroker) SetPrice(symbol string, price float64) {
+	m.prices[symbol] = price
+}
+
+// PlaceOrder fills a market order immediately at the last price set via
+// SetPrice. An order on the opposite side of an open position closes it and
+// realizes its PnL; otherwise a new position is opened.
+func (m *MockBroker) PlaceOrder(symbol string, qty float64, side alpaca.Side, orderType alpaca.OrderType, timeInForce alpaca.TimeInForce) (string, error) {
+	price, ok := m.prices[symbol]
+	if !ok {
+		return "", fmt.Errorf("backtest: no price recorded for %s", symbol)
+	}
+
+	if pos, open := m.positions[symbol]; open && pos.side != side {
+		pnl := (price - pos.entryPrice) * pos.qty
+		if pos.side == alpaca.Sell {
+			pnl = (pos.entryPrice - price) * pos.qty
+		}
+		m.equity += pnl
+		m.fills = append(m.fills, Fill{Symbol: symbol, Side: pos.side, Qty: pos.qty, EntryPrice: pos.entryPrice, ExitPrice: price, PnL: pnl})
+		delete(m.positions, symbol)
+	} else {
+		m.positions[symbol] = &position{side: side, qty: qty, entryPrice: price}
+	}
+
+	m.nextOrder++
+	orderID := fmt.Sprintf("backtest-%d", m.nextOrder)
+	log.Printf("backtest: simulated %s order for %f shares of %s at %.2f", side, qty, symbol, price)
+	return orderID, nil
+}
+
+// PlaceBracketOrder simulates a bracket order as a plain market entry at
+// the last recorded price; the backtest replay loop doesn't model the
+// stop-loss/take-profit legs firing intraday.
+func (m *MockBroker) PlaceBracketOrder(symbol string, qty float64, side alpaca.Side, entry, stop, target float64) (string, error) {
+	return m.PlaceOrder(symbol, qty, side, alpaca.Market, alpaca.GTC)
+}
+
+// CancelOpenOrders is a no-op: the backtest replay fills orders immediately
+// in PlaceOrder/PlaceBracketOrder and never models resting orders, so there
+// is nothing left open to cancel.
+func (m *MockBroker) CancelOpenOrders(symbol string) error {
+	return nil
+}
+
+// GetAccount returns a synthetic Alpaca account reflecting the current
+// simulated equity.
+func (m *MockBroker) GetAccount() (*alpaca.Account, error) {
+	return &alpaca.Account{Equity: decimal.NewFromFloat(m.equity)}, nil
+}
+
+// GetOpenPositions returns the symbols with a currently open simulated
+// position, expressed as Alpaca positions.
+func (m *MockBroker) GetOpenPositions() ([]alpaca.Position, error) {
+	positions := make([]alpaca.Position, 0, len(m.positions))
+	for symbol, pos := range m.positions {
+		positions = append(positions, alpaca.Position{
+			Symbol: symbol,
+			Qty:    decimal.NewFromFloat(pos.qty),
+		})
+	}
+	return positions, nil
+}
+
+// GetClock returns a synthetic clock that always reports the market as
+// open, since a historical replay isn't gated by real session hours.
+func (m *MockBroker) GetClock() (*alpaca.Clock, error) {
+	return &alpaca.Clock{IsOpen: true}, nil
+}
+
+// Equity returns the current mark-to-market equity, including unrealized
+// PnL on any open position.
+func (m *MockBroker) Equity() float64 {
+	equity := m.equity
+	for symbol, pos := range m.positions {
+		price, ok := m.prices[symbol]
+		if !ok {
+			continue
+		}
+		if pos.side == alpaca.Sell {
+			equity += (pos.entryPrice - price) * pos.qty
+		} else {
+			equity += (price - pos.entryPrice) * pos.qty
+		}
+	}
+	return equity
+}
+
+// Fills returns the closed trades recorded so far.
+func (m *MockBroker) Fills() []Fill {
+	return m.fills
+}
@@ -0,0 +1,260 @@
+package backtest
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"aionvanguard/backend/data"
+	"aionvanguard/backend/risk"
+	"aionvanguard/backend/strategy"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v2/alpaca"
+)
+
+// Config describes the parameters of a single backtest run.
+type Config struct {
+	Symbols         []string
+	StartTime       time.Time
+	EndTime         time.Time
+	InitialBalance  float64
+	RiskPerTrade    float64
+	RiskRewardRatio float64
+}
+
+// EquityPoint is a single sample of the simulated account equity curve.
+type EquityPoint struct {
+	Time   time.Time `json:"time"`
+	Equity float64   `json:"equity"`
+}
+
+// Report summarizes the outcome of a backtest run.
+type Report struct {
+	EquityCurve  []EquityPoint `json:"equityCurve"`
+	Trades       []Fill        `json:"trades"`
+	FinalEquity  float64       `json:"finalEquity"`
+	MaxDrawdown  float64       `json:"maxDrawdown"`
+	SharpeRatio  float64       `json:"sharpeRatio"`
+	SortinoRatio float64       `json:"sortinoRatio"`
+	WinRate      float64       `json:"winRate"`
+}
+
+// Progress reports how far a Run has gotten, after each symbol finishes
+// replaying, so a caller (e.g. the WebSocket "backtest" handler) can stream
+// intermediate status instead of only the final Report.
+type Progress struct {
+	Symbol      string  `json:"symbol"`
+	SymbolIndex int     `json:"symbolIndex"`
+	SymbolCount int     `json:"symbolCount"`
+	Equity      float64 `json:"equity"`
+}
+
+// Engine replays historical candles from a data.Fetcher through a
+// strategy.Strategy, simulating fills against a MockBroker. It mirrors
+// agent.TradingAgent's trade loop, except time is driven by the historical
+// window instead of a wall-clock ticker.
+type Engine struct {
+	Config   Config
+	Fetcher  data.Fetcher
+	Strategy strategy.Strategy
+	broker   *MockBroker
+	risk     *risk.Manager
+
+	// OnProgress, if set, is called once per symbol as Run works through
+	// Config.Symbols.
+	OnProgress func(Progress)
+
+	equityCurve []EquityPoint
+}
+
+// NewEngine creates a backtest Engine around the given historical data
+// source. A mean-reversion-free SMA+RSI strategy matching the live agent's
+// default is used unless the caller overrides Engine.Strategy.
+func NewEngine(cfg Config, fetcher data.Fetcher) *Engine {
+	return &Engine{
+		Config:   cfg,
+		Fetcher:  fetcher,
+		Strategy: strategy.NewCombinedStrategy(20, 50, 14, 70, 30),
+		broker:   NewMockBroker(cfg.InitialBalance),
+		risk:     risk.NewManager(cfg.InitialBalance, cfg.RiskPerTrade/100, 0.05),
+	}
+}
+
+// Run replays every symbol in the config across the configured time range
+// and returns the resulting performance report.
+func (e *Engine) Run() (*Report, error) {
+	for i, symbol := range e.Config.Symbols {
+		if err := e.replaySymbol(symbol); err != nil {
+			return nil, fmt.Errorf("backtest: replaying %s: %w", symbol, err)
+		}
+		if e.OnProgress != nil {
+			e.OnProgress(Progress{
+				Symbol:      symbol,
+				SymbolIndex: i + 1,
+				SymbolCount: len(e.Config.Symbols),
+				Equity:      e.broker.Equity(),
+			})
+		}
+	}
+	return e.buildReport(), nil
+}
+
+// replaySymbol fetches the full history for a symbol once, then walks it
+// day by day, generating a signal from the data seen so far and feeding it
+// through the same sizing and order placement path as the live agent.
+func (e *Engine) replaySymbol(symbol string) error {
+	candles, err := e.Fetcher.FetchHistoricalData(symbol, "D", e.Config.StartTime, e.Config.EndTime)
+	if err != nil {
+		return err
+	}
+
+	closes := candles.GetC()
+	timestamps := candles.GetT()
+
+	for i := e.Strategy.WarmupPeriod(); i < len(closes); i++ {
+		window := make([]float64, i+1)
+		for j, c := range closes[:i+1] {
+			window[j] = float64(c)
+		}
+		price := window[len(window)-1]
+		e.broker.SetPrice(symbol, price)
+		e.risk.UpdateAccountBalance(e.broker.Equity())
+
+		signal := e.Strategy.GenerateSignal(symbol, window)
+		if signal != strategy.Hold {
+			stopLossPrice := price * (1 - 0.02)
+			if signal == strategy.Sell {
+				stopLossPrice = price * (1 + 0.02)
+			}
+
+			qty := e.risk.CalculatePositionSize(price, stopLossPrice)
+			if qty > 0 {
+				side := alpaca.Buy
+				if signal == strategy.Sell {
+					side = alpaca.Sell
+				}
+				if _, err := e.broker.PlaceOrder(symbol, qty, side, alpaca.Market, alpaca.GTC); err != nil {
+					return err
+				}
+			}
+		}
+
+		sampleTime := e.Config.StartTime
+		if i < len(timestamps) {
+			sampleTime = time.Unix(timestamps[i], 0)
+		}
+		e.equityCurve = append(e.equityCurve, EquityPoint{Time: sampleTime, Equity: e.broker.Equity()})
+	}
+
+	return nil
+}
+
+func (e *Engine) buildReport() *Report {
+	returns := dailyReturns(e.equityCurve)
+	wins := 0
+	for _, f := range e.broker.Fills() {
+		if f.PnL > 0 {
+			wins++
+		}
+	}
+	winRate := 0.0
+	if len(e.broker.Fills()) > 0 {
+		winRate = float64(wins) / float64(len(e.broker.Fills()))
+	}
+
+	return &Report{
+		EquityCurve:  e.equityCurve,
+		Trades:       e.broker.Fills(),
+		FinalEquity:  e.broker.Equity(),
+		MaxDrawdown:  maxDrawdown(e.equityCurve),
+		SharpeRatio:  sharpeRatio(returns),
+		SortinoRatio: sortinoRatio(returns),
+		WinRate:      winRate,
+	}
+}
+
+func dailyReturns(curve []EquityPoint) []float64 {
+	if len(curve) < 2 {
+		return nil
+	}
+	returns := make([]float64, 0, len(curve)-1)
+	for i := 1; i < len(curve); i++ {
+		prev := curve[i-1].Equity
+		if prev == 0 {
+			continue
+		}
+		returns = append(returns, (curve[i].Equity-prev)/prev)
+	}
+	return returns
+}
+
+func mean(xs []float64) float64 {
+	if len(xs) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, x := range xs {
+		sum += x
+	}
+	return sum / float64(len(xs))
+}
+
+func stdDev(xs []float64, mu float64) float64 {
+	if len(xs) == 0 {
+		return 0
+	}
+	sumSq := 0.0
+	for _, x := range xs {
+		sumSq += (x - mu) * (x - mu)
+	}
+	return math.Sqrt(sumSq / float64(len(xs)))
+}
+
+func sharpeRatio(returns []float64) float64 {
+	if len(returns) == 0 {
+		return 0
+	}
+	mu := mean(returns)
+	sd := stdDev(returns, mu)
+	if sd == 0 {
+		return 0
+	}
+	return (mu / sd) * math.Sqrt(252)
+}
+
+func sortinoRatio(returns []float64) float64 {
+	if len(returns) == 0 {
+		return 0
+	}
+	mu := mean(returns)
+
+	downside := make([]float64, 0, len(returns))
+	for _, r := range returns {
+		if r < 0 {
+			downside = append(downside, r)
+		}
+	}
+	dd := stdDev(downside, 0)
+	if dd == 0 {
+		return 0
+	}
+	return (mu / dd) * math.Sqrt(252)
+}
+
+func maxDrawdown(curve []EquityPoint) float64 {
+	peak := 0.0
+	worst := 0.0
+	for _, p := range curve {
+		if p.Equity > peak {
+			peak = p.Equity
+		}
+		if peak == 0 {
+			continue
+		}
+		drawdown := (peak - p.Equity) / peak
+		if drawdown > worst {
+			worst = drawdown
+		}
+	}
+	return worst
+}
@@ -0,0 +1,105 @@
+package backtest
+
+import (
+	"testing"
+	"time"
+
+	"aionvanguard/backend/strategy"
+
+	finnhub "github.com/Finnhub-Stock-API/finnhub-go/v2"
+)
+
+// fakeFetcher returns a fixed set of candles regardless of the requested
+// symbol or range.
+type fakeFetcher struct {
+	candles finnhub.StockCandles
+}
+
+func (f *fakeFetcher) FetchHistoricalData(symbol, resolution string, from, to time.Time) (finnhub.StockCandles, error) {
+	return f.candles, nil
+}
+
+// holdStrategy never signals, so replaySymbol only needs to exercise the
+// window-building and equity-curve sampling, not order placement.
+type holdStrategy struct{}
+
+func (holdStrategy) GenerateSignal(symbol string, closePrices []float64) strategy.Signal {
+	return strategy.Hold
+}
+
+func (holdStrategy) WarmupPeriod() int { return 2 }
+
+func TestReplaySymbolConvertsFinnhubCandles(t *testing.T) {
+	closes := []float32{10, 10.5, 11, 9.5, 10.25}
+	timestamps := []int64{1, 2, 3, 4, 5}
+	candles := finnhub.NewStockCandles()
+	candles.SetC(closes)
+	candles.SetT(timestamps)
+
+	engine := NewEngine(Config{
+		Symbols:        []string{"AAPL"},
+		StartTime:      time.Unix(0, 0),
+		EndTime:        time.Unix(10, 0),
+		InitialBalance: 10000,
+	}, &fakeFetcher{candles: *candles})
+	engine.Strategy = holdStrategy{}
+
+	report, err := engine.Run()
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+
+	// replaySymbol starts at WarmupPeriod() and samples one equity point per
+	// remaining bar.
+	wantPoints := len(closes) - engine.Strategy.WarmupPeriod()
+	if len(report.EquityCurve) != wantPoints {
+		t.Fatalf("EquityCurve length = %d, want %d", len(report.EquityCurve), wantPoints)
+	}
+
+	last := report.EquityCurve[len(report.EquityCurve)-1]
+	if last.Equity != 10000 {
+		t.Fatalf("FinalEquity = %f, want unchanged starting balance %f (hold strategy placed no orders)", last.Equity, 10000.0)
+	}
+	if last.Time.Unix() != timestamps[len(timestamps)-1] {
+		t.Fatalf("last sample time = %v, want unix %d", last.Time, timestamps[len(timestamps)-1])
+	}
+}
+
+// TestRunReportsProgressPerSymbol guards against Run only ever delivering
+// the final Report: the caller-facing WebSocket handler streams
+// intermediate status off OnProgress, so it must fire once per symbol as
+// Run works through the configured list.
+func TestRunReportsProgressPerSymbol(t *testing.T) {
+	closes := []float32{10, 10.5, 11, 9.5, 10.25}
+	timestamps := []int64{1, 2, 3, 4, 5}
+	candles := finnhub.NewStockCandles()
+	candles.SetC(closes)
+	candles.SetT(timestamps)
+
+	engine := NewEngine(Config{
+		Symbols:        []string{"AAPL", "MSFT"},
+		StartTime:      time.Unix(0, 0),
+		EndTime:        time.Unix(10, 0),
+		InitialBalance: 10000,
+	}, &fakeFetcher{candles: *candles})
+	engine.Strategy = holdStrategy{}
+
+	var progress []Progress
+	engine.OnProgress = func(p Progress) { progress = append(progress, p) }
+
+	if _, err := engine.Run(); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+
+	if len(progress) != len(engine.Config.Symbols) {
+		t.Fatalf("OnProgress called %d times, want %d (once per symbol)", len(progress), len(engine.Config.Symbols))
+	}
+	for i, p := range progress {
+		if p.Symbol != engine.Config.Symbols[i] {
+			t.Fatalf("progress[%d].Symbol = %q, want %q", i, p.Symbol, engine.Config.Symbols[i])
+		}
+		if p.SymbolIndex != i+1 || p.SymbolCount != len(engine.Config.Symbols) {
+			t.Fatalf("progress[%d] = %+v, want SymbolIndex %d, SymbolCount %d", i, p, i+1, len(engine.Config.Symbols))
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package broker
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"os"
 
@@ -8,11 +10,32 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+// Broker defines the trading operations required by the agent, allowing a
+// live Alpaca connection and a simulated backtest broker to be used
+// interchangeably.
+type Broker interface {
+	PlaceOrder(symbol string, qty float64, side alpaca.Side, orderType alpaca.OrderType, timeInForce alpaca.TimeInForce) (string, error)
+	PlaceBracketOrder(symbol string, qty float64, side alpaca.Side, entry, stop, target float64) (string, error)
+	// CancelOpenOrders cancels every resting order in the given symbol. A
+	// bracket's stop-loss/take-profit legs stay open at the broker once the
+	// position itself is flattened by a separate order (a forced exit, a
+	// trailing stop, or EOD liquidation), so this must be called whenever a
+	// position is closed outside of its own bracket resolving naturally, or
+	// a stale leg can later fill against zero position and open a fresh,
+	// unintended one.
+	CancelOpenOrders(symbol string) error
+	GetAccount() (*alpaca.Account, error)
+	GetOpenPositions() ([]alpaca.Position, error)
+	GetClock() (*alpaca.Clock, error)
+}
+
 // AlpacaBroker handles communication with the Alpaca API.
 type AlpacaBroker struct {
 	client alpaca.Client
 }
 
+var _ Broker = (*AlpacaBroker)(nil)
+
 // NewAlpacaBroker creates and configures a new Alpaca client.
 func NewAlpacaBroker() *AlpacaBroker {
 	apiKey := os.Getenv("ALPACA_API_KEY_ID")
@@ -34,9 +57,10 @@ func NewAlpacaBroker() *AlpacaBroker {
 
 // PlaceOrder executes a trade on Alpaca.
 func (b *AlpacaBroker) PlaceOrder(symbol string, qty float64, side alpaca.Side, orderType alpaca.OrderType, timeInForce alpaca.TimeInForce) (string, error) {
+	orderQty := decimal.NewFromFloat(qty)
 	order, err := b.client.PlaceOrder(alpaca.PlaceOrderRequest{
-		Symbol:      symbol,
-		Qty:         decimal.NewFromFloat(qty),
+		AssetKey:    &symbol,
+		Qty:         &orderQty,
 		Side:        side,
 		Type:        orderType,
 		TimeInForce: timeInForce,
@@ -50,6 +74,69 @@ func (b *AlpacaBroker) PlaceOrder(symbol string, qty float64, side alpaca.Side,
 	return order.ID, nil
 }
 
+// PlaceBracketOrder submits a bracket order: an entry (market, or limit if
+// entry is non-zero) with an attached OCO stop-loss/take-profit pair that
+// Alpaca manages as a unit — once one leg fills, Alpaca cancels the other
+// automatically.
+func (b *AlpacaBroker) PlaceBracketOrder(symbol string, qty float64, side alpaca.Side, entry, stop, target float64) (string, error) {
+	orderQty := decimal.NewFromFloat(qty)
+	req := alpaca.PlaceOrderRequest{
+		AssetKey:    &symbol,
+		Qty:         &orderQty,
+		Side:        side,
+		TimeInForce: alpaca.GTC,
+		OrderClass:  alpaca.Bracket,
+	}
+
+	if entry > 0 {
+		limitPrice := decimal.NewFromFloat(entry)
+		req.Type = alpaca.Limit
+		req.LimitPrice = &limitPrice
+	} else {
+		req.Type = alpaca.Market
+	}
+
+	stopPrice := decimal.NewFromFloat(stop)
+	targetPrice := decimal.NewFromFloat(target)
+	req.StopLoss = &alpaca.StopLoss{StopPrice: &stopPrice}
+	req.TakeProfit = &alpaca.TakeProfit{LimitPrice: &targetPrice}
+
+	order, err := b.client.PlaceOrder(req)
+	if err != nil {
+		return "", err
+	}
+
+	log.Printf("Placed bracket %s order for %f shares of %s (stop %.2f, target %.2f). Order ID: %s", side, qty, symbol, stop, target, order.ID)
+	return order.ID, nil
+}
+
+// CancelOpenOrders cancels every open order for symbol, used to clear a
+// bracket's resting stop-loss/take-profit legs once the position they guard
+// has been flattened by some other means. A failure cancelling one order
+// doesn't stop it from attempting the rest, since a bracket's other leg left
+// resting is exactly the stale-order risk this exists to close off; all
+// errors encountered are joined into the returned error.
+func (b *AlpacaBroker) CancelOpenOrders(symbol string) error {
+	status := "open"
+	orders, err := b.client.ListOrdersWithRequest(alpaca.ListOrdersRequest{
+		Status:  &status,
+		Symbols: &symbol,
+	})
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, order := range orders {
+		if err := b.client.CancelOrder(order.ID); err != nil {
+			errs = append(errs, fmt.Errorf("cancel order %s: %w", order.ID, err))
+			continue
+		}
+		log.Printf("Cancelled resting order %s for %s", order.ID, symbol)
+	}
+	return errors.Join(errs...)
+}
+
 // GetAccount retrieves the current Alpaca account information.
 func (b *AlpacaBroker) GetAccount() (*alpaca.Account, error) {
 	return b.client.GetAccount()
@@ -59,3 +146,9 @@ func (b *AlpacaBroker) GetAccount() (*alpaca.Account, error) {
 func (b *AlpacaBroker) GetOpenPositions() ([]alpaca.Position, error) {
 	return b.client.ListPositions()
 }
+
+// GetClock retrieves the current market clock, including whether the
+// market is open and the next open/close times.
+func (b *AlpacaBroker) GetClock() (*alpaca.Clock, error) {
+	return b.client.GetClock()
+}
@@ -0,0 +1,171 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v2/alpaca"
+	"github.com/shopspring/decimal"
+)
+
+// capturingClient is a minimal alpaca.Client that records the last
+// PlaceOrderRequest it received instead of making a network call. Only
+// PlaceOrder is exercised by these tests; the rest of the interface is
+// unused stubs.
+type capturingClient struct {
+	lastReq alpaca.PlaceOrderRequest
+
+	// ordersToList and cancelErrs drive ListOrdersWithRequest/CancelOrder for
+	// TestCancelOpenOrdersCancelsEveryOrderDespiteFailures.
+	ordersToList      []alpaca.Order
+	cancelErrs        map[string]error
+	cancelledOrderIDs []string
+}
+
+var _ alpaca.Client = (*capturingClient)(nil)
+
+func (c *capturingClient) PlaceOrder(req alpaca.PlaceOrderRequest) (*alpaca.Order, error) {
+	c.lastReq = req
+	return &alpaca.Order{ID: "order-1"}, nil
+}
+
+func (c *capturingClient) GetAccount() (*alpaca.Account, error) { return &alpaca.Account{}, nil }
+func (c *capturingClient) GetAccountConfigurations() (*alpaca.AccountConfigurations, error) {
+	return nil, nil
+}
+func (c *capturingClient) UpdateAccountConfigurations(alpaca.AccountConfigurationsRequest) (*alpaca.AccountConfigurations, error) {
+	return nil, nil
+}
+func (c *capturingClient) GetAccountActivities(*string, *alpaca.AccountActivitiesRequest) ([]alpaca.AccountActivity, error) {
+	return nil, nil
+}
+func (c *capturingClient) GetPortfolioHistory(*string, *alpaca.RangeFreq, *time.Time, bool) (*alpaca.PortfolioHistory, error) {
+	return nil, nil
+}
+func (c *capturingClient) ListPositions() ([]alpaca.Position, error)    { return nil, nil }
+func (c *capturingClient) GetPosition(string) (*alpaca.Position, error) { return nil, nil }
+func (c *capturingClient) CloseAllPositions() error                     { return nil }
+func (c *capturingClient) ClosePosition(string) error                   { return nil }
+func (c *capturingClient) GetClock() (*alpaca.Clock, error)             { return &alpaca.Clock{}, nil }
+func (c *capturingClient) GetCalendar(*string, *string) ([]alpaca.CalendarDay, error) {
+	return nil, nil
+}
+func (c *capturingClient) ListOrders(*string, *time.Time, *int, *bool) ([]alpaca.Order, error) {
+	return nil, nil
+}
+func (c *capturingClient) ListOrdersWithRequest(alpaca.ListOrdersRequest) ([]alpaca.Order, error) {
+	return c.ordersToList, nil
+}
+func (c *capturingClient) GetOrder(string) (*alpaca.Order, error) { return nil, nil }
+func (c *capturingClient) GetOrderByClientOrderID(string) (*alpaca.Order, error) {
+	return nil, nil
+}
+func (c *capturingClient) ReplaceOrder(string, alpaca.ReplaceOrderRequest) (*alpaca.Order, error) {
+	return nil, nil
+}
+func (c *capturingClient) CancelOrder(orderID string) error {
+	c.cancelledOrderIDs = append(c.cancelledOrderIDs, orderID)
+	return c.cancelErrs[orderID]
+}
+func (c *capturingClient) CancelAllOrders() error                     { return nil }
+func (c *capturingClient) ListAssets(*string) ([]alpaca.Asset, error) { return nil, nil }
+func (c *capturingClient) GetAsset(string) (*alpaca.Asset, error)     { return nil, nil }
+func (c *capturingClient) StreamTradeUpdates(context.Context, func(alpaca.TradeUpdate)) error {
+	return nil
+}
+func (c *capturingClient) StreamTradeUpdatesInBackground(context.Context, func(alpaca.TradeUpdate)) {
+}
+
+// TestPlaceOrderBuildsValidRequest guards against building
+// alpaca.PlaceOrderRequest with the wrong field names/types (Symbol/Qty
+// instead of the real AssetKey *string/Qty *decimal.Decimal), which
+// compiles against no version of the SDK actually vendored here.
+func TestPlaceOrderBuildsValidRequest(t *testing.T) {
+	client := &capturingClient{}
+	b := &AlpacaBroker{client: client}
+
+	orderID, err := b.PlaceOrder("AAPL", 10, alpaca.Buy, alpaca.Market, alpaca.Day)
+	if err != nil {
+		t.Fatalf("PlaceOrder returned error: %v", err)
+	}
+	if orderID != "order-1" {
+		t.Fatalf("orderID = %q, want %q", orderID, "order-1")
+	}
+
+	req := client.lastReq
+	if req.AssetKey == nil || *req.AssetKey != "AAPL" {
+		t.Fatalf("AssetKey = %v, want \"AAPL\"", req.AssetKey)
+	}
+	if req.Qty == nil || !req.Qty.Equal(decimal.NewFromFloat(10)) {
+		t.Fatalf("Qty = %v, want 10", req.Qty)
+	}
+	if req.Side != alpaca.Buy {
+		t.Fatalf("Side = %v, want %v", req.Side, alpaca.Buy)
+	}
+	if req.Type != alpaca.Market {
+		t.Fatalf("Type = %v, want %v", req.Type, alpaca.Market)
+	}
+	if req.TimeInForce != alpaca.Day {
+		t.Fatalf("TimeInForce = %v, want %v", req.TimeInForce, alpaca.Day)
+	}
+}
+
+// TestPlaceBracketOrderBuildsValidRequest guards against building
+// alpaca.PlaceOrderRequest with the wrong field names/types (Symbol/Qty
+// instead of the real AssetKey *string/Qty *decimal.Decimal), which
+// compiles against no version of the SDK actually vendored here.
+func TestPlaceBracketOrderBuildsValidRequest(t *testing.T) {
+	client := &capturingClient{}
+	b := &AlpacaBroker{client: client}
+
+	orderID, err := b.PlaceBracketOrder("AAPL", 10, alpaca.Buy, 0, 95, 110)
+	if err != nil {
+		t.Fatalf("PlaceBracketOrder returned error: %v", err)
+	}
+	if orderID != "order-1" {
+		t.Fatalf("orderID = %q, want %q", orderID, "order-1")
+	}
+
+	req := client.lastReq
+	if req.AssetKey == nil || *req.AssetKey != "AAPL" {
+		t.Fatalf("AssetKey = %v, want \"AAPL\"", req.AssetKey)
+	}
+	if req.Qty == nil || !req.Qty.Equal(decimal.NewFromFloat(10)) {
+		t.Fatalf("Qty = %v, want 10", req.Qty)
+	}
+	if req.OrderClass != alpaca.Bracket {
+		t.Fatalf("OrderClass = %v, want %v", req.OrderClass, alpaca.Bracket)
+	}
+	if req.Type != alpaca.Market {
+		t.Fatalf("Type = %v, want %v (entry == 0)", req.Type, alpaca.Market)
+	}
+	if req.StopLoss == nil || req.StopLoss.StopPrice == nil || !req.StopLoss.StopPrice.Equal(decimal.NewFromFloat(95)) {
+		t.Fatalf("StopLoss.StopPrice = %v, want 95", req.StopLoss)
+	}
+	if req.TakeProfit == nil || req.TakeProfit.LimitPrice == nil || !req.TakeProfit.LimitPrice.Equal(decimal.NewFromFloat(110)) {
+		t.Fatalf("TakeProfit.LimitPrice = %v, want 110", req.TakeProfit)
+	}
+}
+
+// TestCancelOpenOrdersCancelsEveryOrderDespiteFailures guards against
+// bailing out after the first failed cancellation: a bracket's stop-loss
+// and take-profit legs are separate resting orders, and leaving either one
+// uncancelled because the other failed first defeats the point of the call.
+func TestCancelOpenOrdersCancelsEveryOrderDespiteFailures(t *testing.T) {
+	client := &capturingClient{
+		ordersToList: []alpaca.Order{{ID: "stop-leg"}, {ID: "target-leg"}},
+		cancelErrs:   map[string]error{"stop-leg": errors.New("transient API error")},
+	}
+	b := &AlpacaBroker{client: client}
+
+	err := b.CancelOpenOrders("AAPL")
+	if err == nil {
+		t.Fatal("CancelOpenOrders: expected an error from the failed cancellation")
+	}
+
+	if len(client.cancelledOrderIDs) != 2 {
+		t.Fatalf("cancelledOrderIDs = %v, want both legs attempted", client.cancelledOrderIDs)
+	}
+}
@@ -0,0 +1,33 @@
+// Package config loads the agent's pluggable strategy configuration from
+// a YAML file, à la bbgo, so the active strategy and its parameters can be
+// changed without recompiling.
+package config
+
+import (
+	"os"
+
+	"aionvanguard/backend/strategy"
+
+	"gopkg.in/yaml.v3"
+)
+
+// StrategyConfig selects a registered strategy.Strategy by id and supplies
+// its parameters.
+type StrategyConfig struct {
+	ID     string          `yaml:"id" json:"id"`
+	Params strategy.Params `yaml:"params" json:"params"`
+}
+
+// LoadStrategyConfig reads and parses a YAML strategy configuration file.
+func LoadStrategyConfig(path string) (*StrategyConfig, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg StrategyConfig
+	if err := yaml.Unmarshal(raw, &cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
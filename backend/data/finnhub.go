@@ -9,11 +9,20 @@ import (
 	finnhub "github.com/Finnhub-Stock-API/finnhub-go/v2"
 )
 
+// Fetcher defines the historical data operations required by the agent,
+// allowing a live Finnhub connection and a simulated backtest source to be
+// used interchangeably.
+type Fetcher interface {
+	FetchHistoricalData(symbol, resolution string, from, to time.Time) (finnhub.StockCandles, error)
+}
+
 // FinnhubFetcher handles communication with the Finnhub API.
 type FinnhubFetcher struct {
 	client *finnhub.DefaultApiService
 }
 
+var _ Fetcher = (*FinnhubFetcher)(nil)
+
 // NewFinnhubFetcher creates and configures a new Finnhub client.
 func NewFinnhubFetcher() *FinnhubFetcher {
 	apiKey := os.Getenv("FINNHUB_API_KEY")
@@ -30,7 +39,12 @@ func NewFinnhubFetcher() *FinnhubFetcher {
 
 // FetchHistoricalData retrieves historical candle data for a given symbol.
 func (f *FinnhubFetcher) FetchHistoricalData(symbol, resolution string, from, to time.Time) (finnhub.StockCandles, error) {
-	candles, _, err := f.client.StockCandles(context.Background(), symbol, resolution, from.Unix(), to.Unix())
+	candles, _, err := f.client.StockCandles(context.Background()).
+		Symbol(symbol).
+		Resolution(resolution).
+		From(from.Unix()).
+		To(to.Unix()).
+		Execute()
 	if err != nil {
 		return finnhub.StockCandles{}, err
 	}
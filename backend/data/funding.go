@@ -0,0 +1,91 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+)
+
+// FundingRate is a single funding-rate observation for a perpetual futures
+// symbol.
+type FundingRate struct {
+	Symbol          string
+	Rate            float64
+	NextFundingTime time.Time
+}
+
+// FundingRateFetcher polls a perpetual futures exchange for the current
+// funding rate and next funding time of a symbol.
+type FundingRateFetcher struct {
+	client  *http.Client
+	baseURL string
+	apiKey  string
+}
+
+// NewFundingRateFetcher creates and configures a new FundingRateFetcher.
+// The base URL defaults to Binance's USD-M futures API but can be
+// overridden via the FUNDING_RATE_BASE_URL environment variable to point at
+// another venue.
+func NewFundingRateFetcher() *FundingRateFetcher {
+	baseURL := os.Getenv("FUNDING_RATE_BASE_URL")
+	if baseURL == "" {
+		baseURL = "https://fapi.binance.com"
+	}
+
+	return &FundingRateFetcher{
+		client:  &http.Client{Timeout: 10 * time.Second},
+		baseURL: baseURL,
+		apiKey:  os.Getenv("FUNDING_RATE_API_KEY"),
+	}
+}
+
+// premiumIndexResponse mirrors the fields used from the exchange's premium
+// index endpoint.
+type premiumIndexResponse struct {
+	Symbol          string `json:"symbol"`
+	LastFundingRate string `json:"lastFundingRate"`
+	NextFundingTime int64  `json:"nextFundingTime"`
+}
+
+// FetchFundingRate retrieves the current funding rate and next funding time
+// for a perpetual futures symbol.
+func (f *FundingRateFetcher) FetchFundingRate(ctx context.Context, symbol string) (FundingRate, error) {
+	url := fmt.Sprintf("%s/fapi/v1/premiumIndex?symbol=%s", f.baseURL, symbol)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return FundingRate{}, err
+	}
+	if f.apiKey != "" {
+		req.Header.Set("X-MBX-APIKEY", f.apiKey)
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return FundingRate{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return FundingRate{}, fmt.Errorf("funding rate request for %s failed with status %d", symbol, resp.StatusCode)
+	}
+
+	var parsed premiumIndexResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return FundingRate{}, err
+	}
+
+	var rate float64
+	if _, err := fmt.Sscanf(parsed.LastFundingRate, "%g", &rate); err != nil {
+		log.Printf("Error parsing funding rate for %s: %v", symbol, err)
+	}
+
+	return FundingRate{
+		Symbol:          symbol,
+		Rate:            rate,
+		NextFundingTime: time.UnixMilli(parsed.NextFundingTime),
+	}, nil
+}
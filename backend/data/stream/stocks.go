@@ -0,0 +1,152 @@
+// Package stream wraps Alpaca's v2 streaming market-data client with the
+// rolling per-symbol close-price window the strategy package needs, plus
+// automatic reconnection so a dropped WebSocket doesn't kill the trading
+// loop.
+package stream
+
+import (
+	"context"
+	"log"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v2/marketdata/stream"
+)
+
+// BarHandler is invoked with a symbol's latest closing price each time a
+// new minute bar closes.
+type BarHandler func(symbol string, closes []float64)
+
+// StocksClient subscribes to minute bars for a set of symbols, keeps a
+// rolling window of closes per symbol, and invokes a handler on every bar
+// close. It reconnects with exponential backoff if the underlying
+// WebSocket drops.
+type StocksClient struct {
+	symbols    []string
+	windowSize int
+	handler    BarHandler
+
+	mu      sync.Mutex
+	windows map[string][]float64
+
+	client stream.StocksClient
+}
+
+// NewStocksClient creates a StocksClient for the given symbols. windowSize
+// caps how many closes are retained per symbol; handler is called with the
+// updated window every time a bar closes.
+func NewStocksClient(symbols []string, windowSize int, handler BarHandler) *StocksClient {
+	return &StocksClient{
+		symbols:    symbols,
+		windowSize: windowSize,
+		handler:    handler,
+		windows:    make(map[string][]float64),
+	}
+}
+
+// Available reports whether streaming credentials are configured. Callers
+// should fall back to polling historical data when this returns false.
+func Available() bool {
+	return os.Getenv("ALPACA_API_KEY_ID") != "" && os.Getenv("ALPACA_API_SECRET_KEY") != ""
+}
+
+// Run connects to the Alpaca stream and blocks, dispatching bars to the
+// configured handler until ctx is canceled. On a connection drop it
+// reconnects with exponential backoff, capped at one minute.
+func (c *StocksClient) Run(ctx context.Context) error {
+	backoff := time.Second
+
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		client := stream.NewStocksClient("iex")
+		if err := client.Connect(ctx); err != nil {
+			log.Printf("stream: connect failed, retrying in %s: %v", backoff, err)
+			if !sleep(ctx, backoff) {
+				return ctx.Err()
+			}
+			backoff = nextBackoff(backoff)
+			continue
+		}
+
+		if err := client.SubscribeToBars(c.onBar, c.symbols...); err != nil {
+			log.Printf("stream: subscribe failed, retrying in %s: %v", backoff, err)
+			if !sleep(ctx, backoff) {
+				return ctx.Err()
+			}
+			backoff = nextBackoff(backoff)
+			continue
+		}
+
+		c.client = client
+		backoff = time.Second
+		log.Println("stream: connected to Alpaca market data")
+
+		// Terminated blocks until the client gives up reconnecting on its own
+		// (it retries the WebSocket internally) or the context is canceled.
+		select {
+		case err := <-client.Terminated():
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.Printf("stream: disconnected, reconnecting in %s: %v", backoff, err)
+			if !sleep(ctx, backoff) {
+				return ctx.Err()
+			}
+			backoff = nextBackoff(backoff)
+			continue
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Window returns a copy of the current rolling window of closes for a
+// symbol.
+func (c *StocksClient) Window(symbol string) []float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	closes := c.windows[symbol]
+	out := make([]float64, len(closes))
+	copy(out, closes)
+	return out
+}
+
+func (c *StocksClient) onBar(bar stream.Bar) {
+	c.mu.Lock()
+	closes := append(c.windows[bar.Symbol], bar.Close)
+	if len(closes) > c.windowSize {
+		closes = closes[len(closes)-c.windowSize:]
+	}
+	c.windows[bar.Symbol] = closes
+	windowCopy := make([]float64, len(closes))
+	copy(windowCopy, closes)
+	c.mu.Unlock()
+
+	if c.handler != nil {
+		c.handler(bar.Symbol, windowCopy)
+	}
+}
+
+func nextBackoff(current time.Duration) time.Duration {
+	next := current * 2
+	if next > time.Minute {
+		next = time.Minute
+	}
+	// Add jitter so many symbols/agents reconnecting at once don't thunder.
+	jitter := time.Duration(rand.Int63n(int64(next) / 4))
+	return next + jitter
+}
+
+func sleep(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-time.After(d):
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -2,10 +2,19 @@ package main
 
 import (
 	"encoding/json"
+	"flag"
 	"log"
 	"net/http"
+	"os"
+	"strings"
+	"time"
 
 	"aionvanguard/backend/agent"
+	"aionvanguard/backend/backtest"
+	"aionvanguard/backend/broker"
+	appconfig "aionvanguard/backend/config"
+	"aionvanguard/backend/data"
+	"aionvanguard/backend/strategy"
 	"github.com/gorilla/websocket"
 )
 
@@ -60,6 +69,20 @@ func handleConnections(w http.ResponseWriter, r *http.Request) {
 				continue
 			}
 			tradingAgent = agent.NewTradingAgent(&config, ws)
+			if config.Symbol != "" {
+				// The funding-rate arbitrage strategy hedges a spot long
+				// against a short perpetual future, each leg placed through
+				// its own broker session. Alpaca has no perpetual-futures
+				// product, so there is no "futures" broker to wire up here
+				// yet; registering AlpacaBroker under both names would quietly
+				// submit the "futures" leg as a second equity order against
+				// the same paper account, not a funding-bearing perp short.
+				// Leave "futures" unregistered: evaluateXFunding no-ops until
+				// both sessions are present, so the strategy stays inactive
+				// rather than running against the wrong instrument.
+				tradingAgent.AddSession("spot", broker.NewAlpacaBroker())
+				log.Printf("xfunding: no futures-capable broker configured for %s; the funding-rate strategy will stay inactive until one is wired up as the \"futures\" session", config.Symbol)
+			}
 			tradingAgent.Start()
 			log.Println("Agent started with config:", config)
 
@@ -68,11 +91,118 @@ func handleConnections(w http.ResponseWriter, r *http.Request) {
 				tradingAgent.Stop()
 				log.Println("Agent stopped")
 			}
+
+		case "backtest":
+			var cfg backtest.Config
+			if err := json.Unmarshal(msg.Payload, &cfg); err != nil {
+				log.Println("Error unmarshalling backtest config:", err)
+				continue
+			}
+			go runBacktestOverWS(ws, cfg)
+
+		case "configure":
+			if tradingAgent == nil {
+				log.Println("Received configure message with no running agent")
+				continue
+			}
+			var strategyCfg appconfig.StrategyConfig
+			if err := json.Unmarshal(msg.Payload, &strategyCfg); err != nil {
+				log.Println("Error unmarshalling strategy config:", err)
+				continue
+			}
+			newStrategy, err := strategy.New(strategyCfg.ID, strategyCfg.Params)
+			if err != nil {
+				log.Println("Error resolving strategy:", err)
+				continue
+			}
+			tradingAgent.SetStrategy(newStrategy)
+			log.Println("Agent strategy reconfigured to:", strategyCfg.ID)
 		}
 	}
 }
 
+// runBacktestOverWS runs a backtest and streams its progress and final
+// report back to the client as "backtest_progress" and "backtest_report"
+// messages.
+func runBacktestOverWS(ws *websocket.Conn, cfg backtest.Config) {
+	sendWSMessage(ws, "backtest_progress", map[string]string{"status": "started"})
+
+	engine := backtest.NewEngine(cfg, data.NewFinnhubFetcher())
+	engine.OnProgress = func(p backtest.Progress) {
+		sendWSMessage(ws, "backtest_progress", p)
+	}
+	report, err := engine.Run()
+	if err != nil {
+		sendWSMessage(ws, "backtest_progress", map[string]string{"status": "error", "error": err.Error()})
+		return
+	}
+
+	sendWSMessage(ws, "backtest_report", report)
+}
+
+// sendWSMessage marshals payload and writes it to the connection as a
+// Message of the given type.
+func sendWSMessage(ws *websocket.Conn, msgType string, payload interface{}) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		log.Println("Error marshalling message payload:", err)
+		return
+	}
+	if err := ws.WriteJSON(Message{Type: msgType, Payload: raw}); err != nil {
+		log.Println("Error writing WebSocket message:", err)
+	}
+}
+
+// runBacktestCLI parses backtest flags from argv and prints the resulting
+// report as JSON, for running a backtest outside of the WebSocket server.
+func runBacktestCLI(args []string) {
+	fs := flag.NewFlagSet("backtest", flag.ExitOnError)
+	symbols := fs.String("symbols", "AAPL", "comma-separated list of symbols to replay")
+	start := fs.String("start", time.Now().AddDate(-1, 0, 0).Format("2006-01-02"), "start date (YYYY-MM-DD)")
+	end := fs.String("end", time.Now().Format("2006-01-02"), "end date (YYYY-MM-DD)")
+	balance := fs.Float64("balance", 10000.0, "initial account balance")
+	riskPerTrade := fs.Float64("risk-per-trade", 1.0, "risk per trade as a percentage of balance")
+	riskReward := fs.Float64("risk-reward", 2.0, "risk/reward ratio for take-profit sizing")
+	fs.Parse(args)
+
+	startTime, err := time.Parse("2006-01-02", *start)
+	if err != nil {
+		log.Fatal("Invalid -start date: ", err)
+	}
+	endTime, err := time.Parse("2006-01-02", *end)
+	if err != nil {
+		log.Fatal("Invalid -end date: ", err)
+	}
+
+	cfg := backtest.Config{
+		Symbols:         strings.Split(*symbols, ","),
+		StartTime:       startTime,
+		EndTime:         endTime,
+		InitialBalance:  *balance,
+		RiskPerTrade:    *riskPerTrade,
+		RiskRewardRatio: *riskReward,
+	}
+
+	engine := backtest.NewEngine(cfg, data.NewFinnhubFetcher())
+	report, err := engine.Run()
+	if err != nil {
+		log.Fatal("Backtest failed: ", err)
+	}
+
+	out, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		log.Fatal("Error marshalling report: ", err)
+	}
+	os.Stdout.Write(out)
+	os.Stdout.WriteString("\n")
+}
+
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "backtest" {
+		runBacktestCLI(os.Args[2:])
+		return
+	}
+
 	http.HandleFunc("/ws", handleConnections)
 
 	log.Println("HTTP server started on :8080")
@@ -0,0 +1,58 @@
+// Package movingaverage provides a fixed-size rolling window over a
+// series of values, used by mean-reversion style strategies to track a
+// moving average and standard deviation incrementally.
+package movingaverage
+
+import "math"
+
+// MovingAverage maintains the last N values added via Update and their
+// running mean and standard deviation.
+type MovingAverage struct {
+	window int
+	values []float64
+}
+
+// New creates a MovingAverage that retains at most window values.
+func New(window int) *MovingAverage {
+	return &MovingAverage{window: window}
+}
+
+// Update appends value to the window, dropping the oldest value once the
+// window is full.
+func (m *MovingAverage) Update(value float64) {
+	m.values = append(m.values, value)
+	if len(m.values) > m.window {
+		m.values = m.values[len(m.values)-m.window:]
+	}
+}
+
+// Length returns how many values are currently in the window.
+func (m *MovingAverage) Length() int {
+	return len(m.values)
+}
+
+// Mean returns the average of the values currently in the window.
+func (m *MovingAverage) Mean() float64 {
+	if len(m.values) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, v := range m.values {
+		sum += v
+	}
+	return sum / float64(len(m.values))
+}
+
+// StdDev returns the population standard deviation of the values
+// currently in the window.
+func (m *MovingAverage) StdDev() float64 {
+	if len(m.values) == 0 {
+		return 0
+	}
+	mean := m.Mean()
+	sumSq := 0.0
+	for _, v := range m.values {
+		sumSq += (v - mean) * (v - mean)
+	}
+	return math.Sqrt(sumSq / float64(len(m.values)))
+}
@@ -0,0 +1,28 @@
+package persistence
+
+import "encoding/json"
+
+// migration upgrades a State from its current schema version to the next
+// one.
+type migration func(*State)
+
+// migrations[i] upgrades from schema i to schema i+1. A nil entry means
+// the upgrade is just the version bump (no field changes needed).
+var migrations = []migration{
+	// Schema 0 -> 1: initial schema.
+	nil,
+}
+
+// applyMigrations upgrades state to CurrentSchema in place, and fills in
+// any nil maps a pre-migration blob might be missing.
+func applyMigrations(state *State) {
+	for state.Schema < CurrentSchema {
+		if m := migrations[state.Schema]; m != nil {
+			m(state)
+		}
+		state.Schema++
+	}
+	if state.StrategyState == nil {
+		state.StrategyState = make(map[string]json.RawMessage)
+	}
+}
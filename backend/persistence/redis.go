@@ -0,0 +1,64 @@
+package persistence
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const defaultRedisKey = "aionvanguard:agent_state"
+
+// RedisStore persists State as a single JSON blob under a Redis key.
+type RedisStore struct {
+	client *redis.Client
+	key    string
+}
+
+// NewRedisStore creates a RedisStore against the given address. If key is
+// empty, defaultRedisKey is used.
+func NewRedisStore(addr, key string) *RedisStore {
+	if key == "" {
+		key = defaultRedisKey
+	}
+	return &RedisStore{
+		client: redis.NewClient(&redis.Options{Addr: addr}),
+		key:    key,
+	}
+}
+
+var _ Store = (*RedisStore)(nil)
+
+// Load returns the persisted state, or an empty state if none has been
+// saved yet.
+func (s *RedisStore) Load(ctx context.Context) (*State, error) {
+	raw, err := s.client.Get(ctx, s.key).Bytes()
+	if err == redis.Nil {
+		return emptyState(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var state State
+	if err := json.Unmarshal(raw, &state); err != nil {
+		return nil, err
+	}
+	applyMigrations(&state)
+	return &state, nil
+}
+
+// Save overwrites the persisted state.
+func (s *RedisStore) Save(ctx context.Context, state *State) error {
+	state.Schema = CurrentSchema
+	raw, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, s.key, raw, 0).Err()
+}
+
+// Close closes the underlying Redis client.
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
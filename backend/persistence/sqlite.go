@@ -0,0 +1,72 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// SQLiteStore persists State as a single JSON blob in a SQLite database.
+// Keeping the whole snapshot in one blob means new State fields just ride
+// along without a schema change to the table itself; applyMigrations
+// handles upgrading the blob's shape.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore opens (creating if necessary) a SQLite database at path
+// and ensures its schema exists.
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS agent_state (id INTEGER PRIMARY KEY CHECK (id = 1), data TEXT NOT NULL)`); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+var _ Store = (*SQLiteStore)(nil)
+
+// Load returns the persisted state, or an empty state if none has been
+// saved yet.
+func (s *SQLiteStore) Load(ctx context.Context) (*State, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT data FROM agent_state WHERE id = 1`)
+
+	var raw string
+	if err := row.Scan(&raw); err != nil {
+		if err == sql.ErrNoRows {
+			return emptyState(), nil
+		}
+		return nil, err
+	}
+
+	var state State
+	if err := json.Unmarshal([]byte(raw), &state); err != nil {
+		return nil, err
+	}
+	applyMigrations(&state)
+	return &state, nil
+}
+
+// Save overwrites the persisted state.
+func (s *SQLiteStore) Save(ctx context.Context, state *State) error {
+	state.Schema = CurrentSchema
+	raw, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx,
+		`INSERT INTO agent_state (id, data) VALUES (1, ?) ON CONFLICT(id) DO UPDATE SET data = excluded.data`,
+		string(raw))
+	return err
+}
+
+// Close closes the underlying database connection.
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
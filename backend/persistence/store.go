@@ -0,0 +1,55 @@
+// Package persistence snapshots the trading agent's daily risk counters
+// and per-strategy state so a restart doesn't lose the daily risk budget
+// or cause a strategy like xfunding to double-open a position it already
+// holds.
+package persistence
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// CurrentSchema is the State schema version this build produces. Store
+// implementations run applyMigrations on Load so data written by an older
+// build is upgraded in place.
+const CurrentSchema = 1
+
+// State is the full snapshot of agent state persisted across restarts.
+type State struct {
+	Schema int `json:"schema"`
+
+	// DailyLossIncurred and LastResetDate back risk.Manager's daily loss
+	// budget; LastResetDate is the exchange-calendar session date
+	// (YYYY-MM-DD) the counter was last zeroed for.
+	DailyLossIncurred float64 `json:"dailyLossIncurred"`
+	LastResetDate     string  `json:"lastResetDate"`
+
+	// StrategyState holds opaque per-strategy state (e.g. xfunding's open
+	// hedge positions), keyed by strategy id.
+	StrategyState map[string]json.RawMessage `json:"strategyState"`
+}
+
+// Store persists and restores agent State.
+type Store interface {
+	Load(ctx context.Context) (*State, error)
+	Save(ctx context.Context, state *State) error
+	Close() error
+}
+
+// New constructs a Store for the given backend ("sqlite" or "redis").
+// dsn is a file path for sqlite, or a host:port address for redis.
+func New(backend, dsn string) (Store, error) {
+	switch backend {
+	case "sqlite":
+		return NewSQLiteStore(dsn)
+	case "redis":
+		return NewRedisStore(dsn, ""), nil
+	default:
+		return nil, fmt.Errorf("persistence: unknown backend %q", backend)
+	}
+}
+
+func emptyState() *State {
+	return &State{Schema: CurrentSchema, StrategyState: make(map[string]json.RawMessage)}
+}
@@ -0,0 +1,82 @@
+package persistence
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"aionvanguard/backend/strategy/xfunding"
+)
+
+// xfundingStateKey is the StrategyState key xfunding positions are nested
+// under.
+const xfundingStateKey = "xfunding"
+
+// XFundingStore adapts a Store's generic per-strategy StrategyState blob
+// into the xfunding.Store interface, so a restart sees the same open
+// hedge positions xfunding left behind instead of risking a double-open.
+type XFundingStore struct {
+	store Store
+	mu    sync.Mutex
+}
+
+var _ xfunding.Store = (*XFundingStore)(nil)
+
+// NewXFundingStore wraps store for use by strategy/xfunding.
+func NewXFundingStore(store Store) *XFundingStore {
+	return &XFundingStore{store: store}
+}
+
+// Load returns the persisted xfunding position for symbol, if any.
+func (x *XFundingStore) Load(symbol string) (*xfunding.Position, bool, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	positions, _, err := x.loadPositions()
+	if err != nil {
+		return nil, false, err
+	}
+	pos, ok := positions[symbol]
+	return pos, ok, nil
+}
+
+// Save persists pos under symbol.
+func (x *XFundingStore) Save(symbol string, pos *xfunding.Position) error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	positions, state, err := x.loadPositions()
+	if err != nil {
+		return err
+	}
+	positions[symbol] = pos
+
+	raw, err := json.Marshal(positions)
+	if err != nil {
+		return err
+	}
+	state.StrategyState[xfundingStateKey] = raw
+
+	return x.store.Save(context.Background(), state)
+}
+
+// loadPositions reads the current xfunding positions out of the
+// underlying Store's state, returning the state too so Save can write
+// back into the same snapshot.
+func (x *XFundingStore) loadPositions() (map[string]*xfunding.Position, *State, error) {
+	state, err := x.store.Load(context.Background())
+	if err != nil {
+		return nil, nil, err
+	}
+	if state.StrategyState == nil {
+		state.StrategyState = make(map[string]json.RawMessage)
+	}
+
+	positions := make(map[string]*xfunding.Position)
+	if raw, ok := state.StrategyState[xfundingStateKey]; ok {
+		if err := json.Unmarshal(raw, &positions); err != nil {
+			return nil, nil, err
+		}
+	}
+	return positions, state, nil
+}
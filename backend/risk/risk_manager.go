@@ -3,6 +3,7 @@ package risk
 import (
 	"log"
 	"math"
+	"time"
 )
 
 // Manager handles trading risk based on predefined rules.
@@ -11,6 +12,10 @@ type Manager struct {
 	RiskPerTradePercentage  float64
 	DailyRiskLimitPercentage float64
 	DailyLossIncurred       float64
+
+	// NoNewTradesWindow is how long before the market close new entries
+	// are blocked. Zero disables the check.
+	NoNewTradesWindow time.Duration
 }
 
 // NewManager creates and configures a new RiskManager.
@@ -51,6 +56,37 @@ func (m *Manager) DetermineTakeProfit(entryPrice, stopLossPrice, riskRewardRatio
 	return entryPrice - takeProfitDistance
 }
 
+// ShouldStopOpening reports whether new entries should be blocked because
+// now falls within NoNewTradesWindow of the market close. A zero
+// marketClose (clock unavailable) never blocks.
+func (m *Manager) ShouldStopOpening(now, marketClose time.Time) bool {
+	if marketClose.IsZero() || m.NoNewTradesWindow <= 0 {
+		return false
+	}
+	return !now.Before(marketClose.Add(-m.NoNewTradesWindow))
+}
+
+// RecordTradePnL folds a closed trade's realized profit or loss into the
+// daily risk budget. Only losses accumulate into DailyLossIncurred; a
+// winning trade doesn't offset losses already incurred that session.
+func (m *Manager) RecordTradePnL(pnl float64) {
+	if pnl >= 0 {
+		return
+	}
+	m.DailyLossIncurred += -pnl
+	log.Printf("risk: recorded trade loss of %.2f, daily loss now %.2f", -pnl, m.DailyLossIncurred)
+}
+
+// DailyLossLimitExceeded reports whether the day's realized losses have
+// breached DailyRiskLimitPercentage of the account balance. A zero or
+// negative DailyRiskLimitPercentage disables the check.
+func (m *Manager) DailyLossLimitExceeded() bool {
+	if m.DailyRiskLimitPercentage <= 0 {
+		return false
+	}
+	return m.DailyLossIncurred >= m.AccountBalance*m.DailyRiskLimitPercentage
+}
+
 // UpdateAccountBalance sets the account balance to a new value.
 func (m *Manager) UpdateAccountBalance(newBalance float64) {
 	if newBalance > 0 {
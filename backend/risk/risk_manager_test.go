@@ -0,0 +1,37 @@
+package risk
+
+import "testing"
+
+// TestRecordTradePnLOnlyAccumulatesLosses guards against a winning trade
+// offsetting losses already incurred, which would let a single profitable
+// trade silently reopen the daily risk budget.
+func TestRecordTradePnLOnlyAccumulatesLosses(t *testing.T) {
+	m := NewManager(10000, 0.01, 0.02)
+
+	m.RecordTradePnL(-100)
+	if m.DailyLossIncurred != 100 {
+		t.Fatalf("DailyLossIncurred = %f, want 100", m.DailyLossIncurred)
+	}
+
+	m.RecordTradePnL(50)
+	if m.DailyLossIncurred != 100 {
+		t.Fatalf("DailyLossIncurred = %f, want unchanged 100 after a winning trade", m.DailyLossIncurred)
+	}
+}
+
+// TestDailyLossLimitExceeded guards against the daily risk budget
+// persistence in the persistence package building on a counter that never
+// actually gets enforced against DailyRiskLimitPercentage.
+func TestDailyLossLimitExceeded(t *testing.T) {
+	m := NewManager(10000, 0.01, 0.02) // 2% of 10000 = 200
+
+	m.RecordTradePnL(-150)
+	if m.DailyLossLimitExceeded() {
+		t.Fatal("DailyLossLimitExceeded: expected false below the 200 limit")
+	}
+
+	m.RecordTradePnL(-60)
+	if !m.DailyLossLimitExceeded() {
+		t.Fatal("DailyLossLimitExceeded: expected true once losses reach the 200 limit")
+	}
+}
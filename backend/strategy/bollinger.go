@@ -0,0 +1,115 @@
+package strategy
+
+import (
+	"sync"
+
+	"aionvanguard/backend/movingaverage"
+)
+
+func init() {
+	Register("bollinger_reversion", func(p Params) (Strategy, error) {
+		return NewBollingerBandStrategy(
+			int(paramOrDefault(p, "window", 20)),
+			paramOrDefault(p, "num_std_dev", 2.0),
+		), nil
+	})
+}
+
+// BollingerBandStrategy buys when price closes below the lower Bollinger
+// band and sells when it closes above the upper band, betting on reversion
+// to the moving average between them.
+type BollingerBandStrategy struct {
+	Window    int
+	NumStdDev float64
+
+	mu       sync.Mutex
+	position map[string]Signal
+}
+
+var _ StatefulStrategy = (*BollingerBandStrategy)(nil)
+
+// NewBollingerBandStrategy creates a BollingerBandStrategy over the given
+// window with bands numStdDev standard deviations from the mean.
+func NewBollingerBandStrategy(window int, numStdDev float64) *BollingerBandStrategy {
+	return &BollingerBandStrategy{
+		Window:    window,
+		NumStdDev: numStdDev,
+		position:  make(map[string]Signal),
+	}
+}
+
+// WarmupPeriod returns the window size needed before a signal can be
+// generated.
+func (s *BollingerBandStrategy) WarmupPeriod() int {
+	return s.Window
+}
+
+// GenerateSignal computes the Bollinger bands over the trailing window and
+// signals a reversion trade when the latest close is outside them. Once a
+// position is open for a symbol, the same breach no longer re-emits entry
+// signals every bar; the position is closed out (opposite-side signal) once
+// price reverts back to the mean.
+func (s *BollingerBandStrategy) GenerateSignal(symbol string, closePrices []float64) Signal {
+	if len(closePrices) < s.Window {
+		return Hold
+	}
+
+	window := closePrices[len(closePrices)-s.Window:]
+	ma := movingaverage.New(s.Window)
+	for _, c := range window {
+		ma.Update(c)
+	}
+
+	mean := ma.Mean()
+	stdDev := ma.StdDev()
+	upper := mean + s.NumStdDev*stdDev
+	lower := mean - s.NumStdDev*stdDev
+
+	price := closePrices[len(closePrices)-1]
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pos := s.position[symbol]
+
+	switch {
+	case pos != Buy && price < lower:
+		s.position[symbol] = Buy
+		return Buy
+	case pos == Buy && price >= mean:
+		s.position[symbol] = Hold
+		return Sell
+	case pos != Sell && price > upper:
+		s.position[symbol] = Sell
+		return Sell
+	case pos == Sell && price <= mean:
+		s.position[symbol] = Hold
+		return Buy
+	}
+
+	return Hold
+}
+
+// State returns a snapshot of the strategy's per-symbol position (Buy,
+// Sell, or Hold), so a restart can restore it instead of losing track of a
+// position the broker already holds.
+func (s *BollingerBandStrategy) State() map[string]Signal {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := make(map[string]Signal, len(s.position))
+	for symbol, pos := range s.position {
+		state[symbol] = pos
+	}
+	return state
+}
+
+// RestoreState merges state into the strategy's per-symbol position,
+// overriding the Hold default a freshly constructed strategy starts with.
+func (s *BollingerBandStrategy) RestoreState(state map[string]Signal) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for symbol, pos := range state {
+		s.position[symbol] = pos
+	}
+}
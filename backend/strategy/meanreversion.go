@@ -0,0 +1,143 @@
+package strategy
+
+import (
+	"sync"
+
+	"aionvanguard/backend/movingaverage"
+)
+
+func init() {
+	Register("mean_reversion", func(p Params) (Strategy, error) {
+		return NewMeanReversionStrategy(
+			int(paramOrDefault(p, "window", 20)),
+			paramOrDefault(p, "entry_z", 2.0),
+			paramOrDefault(p, "exit_z", 0.5),
+		), nil
+	})
+}
+
+// MeanReversionStrategy buys when price falls entryZ standard deviations
+// below its rolling mean and sells to close at exitZ, and symmetrically
+// for shorts above the mean.
+type MeanReversionStrategy struct {
+	Window int
+	EntryZ float64
+	ExitZ  float64
+
+	mu       sync.Mutex
+	windows  map[string]*movingaverage.MovingAverage
+	lastLen  map[string]int
+	position map[string]Signal
+}
+
+var _ StatefulStrategy = (*MeanReversionStrategy)(nil)
+
+// NewMeanReversionStrategy creates a MeanReversionStrategy over the given
+// window with the given entry/exit z-score thresholds.
+func NewMeanReversionStrategy(window int, entryZ, exitZ float64) *MeanReversionStrategy {
+	return &MeanReversionStrategy{
+		Window:   window,
+		EntryZ:   entryZ,
+		ExitZ:    exitZ,
+		windows:  make(map[string]*movingaverage.MovingAverage),
+		lastLen:  make(map[string]int),
+		position: make(map[string]Signal),
+	}
+}
+
+// WarmupPeriod returns the window size needed before a signal can be
+// generated.
+func (s *MeanReversionStrategy) WarmupPeriod() int {
+	return s.Window
+}
+
+// GenerateSignal maintains a per-symbol moving average of the last Window
+// closes and emits a signal based on how many standard deviations the
+// latest price is from that mean.
+func (s *MeanReversionStrategy) GenerateSignal(symbol string, closePrices []float64) Signal {
+	if len(closePrices) == 0 {
+		return Hold
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ma, ok := s.windows[symbol]
+	// A gap (closePrices didn't grow by exactly one bar since the last
+	// call) or a first sighting of this symbol resets the window. Position
+	// is left alone if RestoreState already seeded it for this symbol, so a
+	// restart's first (necessarily gapped) call doesn't forget a position
+	// the broker still holds.
+	if !ok || len(closePrices) != s.lastLen[symbol]+1 {
+		ma = movingaverage.New(s.Window)
+		start := len(closePrices) - s.Window
+		if start < 0 {
+			start = 0
+		}
+		for _, c := range closePrices[start:] {
+			ma.Update(c)
+		}
+		s.windows[symbol] = ma
+		if _, seeded := s.position[symbol]; !seeded {
+			s.position[symbol] = Hold
+		}
+	} else {
+		ma.Update(closePrices[len(closePrices)-1])
+	}
+	s.lastLen[symbol] = len(closePrices)
+
+	if ma.Length() < s.Window {
+		return Hold
+	}
+
+	stdDev := ma.StdDev()
+	if stdDev == 0 {
+		return Hold
+	}
+
+	price := closePrices[len(closePrices)-1]
+	z := (price - ma.Mean()) / stdDev
+	pos := s.position[symbol]
+
+	switch {
+	case pos != Buy && z < -s.EntryZ:
+		s.position[symbol] = Buy
+		return Buy
+	case pos == Buy && z > -s.ExitZ:
+		s.position[symbol] = Hold
+		return Sell
+	case pos != Sell && z > s.EntryZ:
+		s.position[symbol] = Sell
+		return Sell
+	case pos == Sell && z < s.ExitZ:
+		s.position[symbol] = Hold
+		return Buy
+	}
+
+	return Hold
+}
+
+// State returns a snapshot of the strategy's per-symbol position (Buy,
+// Sell, or Hold), so a restart can restore it instead of losing track of a
+// position the broker already holds.
+func (s *MeanReversionStrategy) State() map[string]Signal {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := make(map[string]Signal, len(s.position))
+	for symbol, pos := range s.position {
+		state[symbol] = pos
+	}
+	return state
+}
+
+// RestoreState merges state into the strategy's per-symbol position,
+// overriding the Hold default a freshly constructed strategy starts with.
+func (s *MeanReversionStrategy) RestoreState(state map[string]Signal) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for symbol, pos := range state {
+		s.position[symbol] = pos
+	}
+}
@@ -0,0 +1,127 @@
+package strategy
+
+import (
+	"math"
+	"sync"
+)
+
+func init() {
+	Register("nr7_breakout", func(p Params) (Strategy, error) {
+		return NewNR7Strategy(int(paramOrDefault(p, "window", 7))), nil
+	})
+}
+
+// NR7Strategy trades breakouts following a narrow-range ("inside bar")
+// day: when the most recent bar's close-to-close range is the narrowest
+// of the last Window bars, it signals a breakout above the window's high
+// or below its low. Only close prices are available here, so bar range is
+// approximated as the close-to-close move rather than the true high/low
+// range.
+type NR7Strategy struct {
+	Window int
+
+	mu       sync.Mutex
+	position map[string]Signal
+}
+
+var _ StatefulStrategy = (*NR7Strategy)(nil)
+
+// NewNR7Strategy creates an NR7Strategy that looks for the narrowest range
+// bar among the last window bars.
+func NewNR7Strategy(window int) *NR7Strategy {
+	return &NR7Strategy{Window: window, position: make(map[string]Signal)}
+}
+
+// WarmupPeriod returns the number of closes needed to evaluate a window
+// of ranges plus the breakout bar.
+func (s *NR7Strategy) WarmupPeriod() int {
+	return s.Window + 2
+}
+
+// GenerateSignal looks for the narrowest-range bar in the trailing window
+// and, if the most recent bar is that narrow-range bar, signals a breakout
+// once price trades beyond the window's high or low. Once a position is
+// open for a symbol, a further breakout no longer re-emits entry signals
+// every bar; the position is closed out (opposite-side signal) once price
+// reverts back to the window's midpoint.
+func (s *NR7Strategy) GenerateSignal(symbol string, closePrices []float64) Signal {
+	if len(closePrices) < s.Window+2 {
+		return Hold
+	}
+
+	recent := closePrices[len(closePrices)-s.Window-1:]
+	ranges := make([]float64, s.Window)
+	for i := 1; i < len(recent); i++ {
+		ranges[i-1] = math.Abs(recent[i] - recent[i-1])
+	}
+
+	narrowest := 0
+	for i, r := range ranges {
+		if r < ranges[narrowest] {
+			narrowest = i
+		}
+	}
+	// The most recent bar being the narrow-range bar is the breakout setup;
+	// absent it there's no new entry, but an existing position can still be
+	// closed out below.
+	isSetup := narrowest == len(ranges)-1
+
+	high, low := recent[0], recent[0]
+	for _, c := range recent[:len(recent)-1] {
+		if c > high {
+			high = c
+		}
+		if c < low {
+			low = c
+		}
+	}
+	mid := (high + low) / 2
+
+	latest := closePrices[len(closePrices)-1]
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pos := s.position[symbol]
+
+	switch {
+	case isSetup && pos != Buy && latest > high:
+		s.position[symbol] = Buy
+		return Buy
+	case pos == Buy && latest <= mid:
+		s.position[symbol] = Hold
+		return Sell
+	case isSetup && pos != Sell && latest < low:
+		s.position[symbol] = Sell
+		return Sell
+	case pos == Sell && latest >= mid:
+		s.position[symbol] = Hold
+		return Buy
+	}
+
+	return Hold
+}
+
+// State returns a snapshot of the strategy's per-symbol position (Buy,
+// Sell, or Hold), so a restart can restore it instead of losing track of a
+// position the broker already holds.
+func (s *NR7Strategy) State() map[string]Signal {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := make(map[string]Signal, len(s.position))
+	for symbol, pos := range s.position {
+		state[symbol] = pos
+	}
+	return state
+}
+
+// RestoreState merges state into the strategy's per-symbol position,
+// overriding the Hold default a freshly constructed strategy starts with.
+func (s *NR7Strategy) RestoreState(state map[string]Signal) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for symbol, pos := range state {
+		s.position[symbol] = pos
+	}
+}
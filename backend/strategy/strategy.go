@@ -1,6 +1,7 @@
 package strategy
 
 import (
+	"fmt"
 	"log"
 
 	"github.com/markcheno/go-talib"
@@ -18,29 +19,111 @@ const (
 	Hold Signal = "HOLD"
 )
 
+// Strategy generates trading signals from historical close prices. Built-in
+// implementations are registered by id in the package registry so the
+// active strategy can be selected from a YAML config file, or switched at
+// runtime via the "configure" WebSocket message, without recompiling.
+type Strategy interface {
+	// GenerateSignal produces a signal for symbol from its historical
+	// close prices, most recent last.
+	GenerateSignal(symbol string, closePrices []float64) Signal
+	// WarmupPeriod is the minimum number of closes GenerateSignal needs
+	// before it can produce a signal other than Hold.
+	WarmupPeriod() int
+}
+
+// StatefulStrategy is implemented by strategies whose signal depends on
+// more than the closePrices window alone, such as a per-symbol open
+// position flag, so a restart can restore that state instead of risking
+// GenerateSignal re-entering a position the broker already holds.
+type StatefulStrategy interface {
+	Strategy
+	// State returns a snapshot of the strategy's per-symbol state, safe to
+	// marshal to JSON.
+	State() map[string]Signal
+	// RestoreState merges state into the strategy's per-symbol state. It is
+	// called once, before the first GenerateSignal call, when resuming from
+	// a persisted snapshot.
+	RestoreState(state map[string]Signal)
+}
+
+// Params holds a strategy's configuration, as parsed from a YAML config
+// file or a "configure" WebSocket message.
+type Params map[string]float64
+
+// Factory constructs a Strategy from its Params.
+type Factory func(params Params) (Strategy, error)
+
+var registry = make(map[string]Factory)
+
+// Register adds a strategy factory to the registry under id. It is
+// typically called from an init() function in the file that defines the
+// strategy.
+func Register(id string, factory Factory) {
+	registry[id] = factory
+}
+
+// New resolves the strategy registered under id and constructs it with the
+// given params.
+func New(id string, params Params) (Strategy, error) {
+	factory, ok := registry[id]
+	if !ok {
+		return nil, fmt.Errorf("strategy: no strategy registered with id %q", id)
+	}
+	return factory(params)
+}
+
+// paramOrDefault returns params[key] if set, otherwise def.
+func paramOrDefault(params Params, key string, def float64) float64 {
+	if v, ok := params[key]; ok {
+		return v
+	}
+	return def
+}
+
+func init() {
+	Register("sma_rsi", func(p Params) (Strategy, error) {
+		return NewCombinedStrategy(
+			int(paramOrDefault(p, "short_window", 20)),
+			int(paramOrDefault(p, "long_window", 50)),
+			int(paramOrDefault(p, "rsi_window", 14)),
+			paramOrDefault(p, "rsi_overbought", 70),
+			paramOrDefault(p, "rsi_oversold", 30),
+		), nil
+	})
+}
+
 // CombinedStrategy implements a trading strategy that combines a Moving Average
 // Crossover with an RSI filter for confirmation.
 type CombinedStrategy struct {
-	ShortWindow    int
-	LongWindow     int
-	RSIWindow      int
-	RSIOverbought  float64
-	RSIOversold    float64
+	ShortWindow   int
+	LongWindow    int
+	RSIWindow     int
+	RSIOverbought float64
+	RSIOversold   float64
 }
 
+var _ Strategy = (*CombinedStrategy)(nil)
+
 // NewCombinedStrategy creates and configures a new CombinedStrategy.
 func NewCombinedStrategy(shortWindow, longWindow, rsiWindow int, rsiOverbought, rsiOversold float64) *CombinedStrategy {
 	return &CombinedStrategy{
-		ShortWindow:    shortWindow,
-		LongWindow:     longWindow,
-		RSIWindow:      rsiWindow,
-		RSIOverbought:  rsiOverbought,
-		RSIOversold:    rsiOversold,
+		ShortWindow:   shortWindow,
+		LongWindow:    longWindow,
+		RSIWindow:     rsiWindow,
+		RSIOverbought: rsiOverbought,
+		RSIOversold:   rsiOversold,
 	}
 }
 
+// WarmupPeriod returns the longest window the strategy needs before it can
+// generate a signal.
+func (s *CombinedStrategy) WarmupPeriod() int {
+	return s.LongWindow
+}
+
 // GenerateSignal generates a trading signal based on the provided historical data.
-func (s *CombinedStrategy) GenerateSignal(closePrices []float64) Signal {
+func (s *CombinedStrategy) GenerateSignal(symbol string, closePrices []float64) Signal {
 	if len(closePrices) < s.LongWindow {
 		log.Println("Not enough data to generate a signal.")
 		return Hold
@@ -61,14 +144,14 @@ func (s *CombinedStrategy) GenerateSignal(closePrices []float64) Signal {
 	// Buy Condition: Bullish Crossover + RSI Confirmation
 	isBullishCrossover := previousShortSMA <= previousLongSMA && latestShortSMA > latestLongSMA
 	if isBullishCrossover && latestRSI < s.RSIOverbought {
-		log.Printf("BUY signal: Bullish crossover and RSI (%.2f) is below %.2f.", latestRSI, s.RSIOverbought)
+		log.Printf("BUY signal for %s: Bullish crossover and RSI (%.2f) is below %.2f.", symbol, latestRSI, s.RSIOverbought)
 		return Buy
 	}
 
 	// Sell Condition: Bearish Crossover + RSI Confirmation
 	isBearishCrossover := previousShortSMA >= previousLongSMA && latestShortSMA < latestLongSMA
 	if isBearishCrossover && latestRSI > s.RSIOversold {
-		log.Printf("SELL signal: Bearish crossover and RSI (%.2f) is above %.2f.", latestRSI, s.RSIOversold)
+		log.Printf("SELL signal for %s: Bearish crossover and RSI (%.2f) is above %.2f.", symbol, latestRSI, s.RSIOversold)
 		return Sell
 	}
 
@@ -0,0 +1,229 @@
+// Package xfunding implements a delta-neutral cross-exchange funding-rate
+// arbitrage strategy: long spot on one broker session, short the
+// equivalent perpetual future on another, harvesting positive funding
+// payments while the two legs hedge each other's price risk.
+package xfunding
+
+import (
+	"context"
+	"log"
+	"math"
+
+	"aionvanguard/backend/broker"
+	"aionvanguard/backend/data"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v2/alpaca"
+)
+
+// State is a stage of the funding-rate hedge state machine.
+type State string
+
+const (
+	// Idle means no hedge is open for the symbol.
+	Idle State = "IDLE"
+	// Hedged means the spot/futures hedge is open and earning funding.
+	Hedged State = "HEDGED"
+)
+
+// Position tracks one symbol's open hedge so restarts don't double-open or
+// lose track of accumulated PnL.
+type Position struct {
+	Symbol     string
+	State      State
+	SpotQty    float64
+	FuturesQty float64
+	PnL        float64
+}
+
+// Store persists Position state keyed by symbol so a restart can resume
+// without re-opening an already-hedged symbol.
+type Store interface {
+	Load(symbol string) (*Position, bool, error)
+	Save(symbol string, pos *Position) error
+}
+
+// InMemoryStore is a Store that keeps positions in process memory. It does
+// not survive restarts and exists as the default for callers that don't
+// wire up the persistence package.
+type InMemoryStore struct {
+	positions map[string]*Position
+}
+
+// NewInMemoryStore creates an empty in-memory Store.
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{positions: make(map[string]*Position)}
+}
+
+// Load returns the stored position for symbol, if any.
+func (s *InMemoryStore) Load(symbol string) (*Position, bool, error) {
+	pos, ok := s.positions[symbol]
+	return pos, ok, nil
+}
+
+// Save stores pos under symbol.
+func (s *InMemoryStore) Save(symbol string, pos *Position) error {
+	s.positions[symbol] = pos
+	return nil
+}
+
+// Strategy runs the funding-rate arbitrage state machine for a single
+// symbol across a spot and a futures broker session.
+type Strategy struct {
+	Spot    broker.Broker
+	Futures broker.Broker
+	Funding *data.FundingRateFetcher
+	Store   Store
+
+	FundingRateHigh float64
+	FundingRateLow  float64
+	Leverage        float64
+
+	// driftTolerance is the fraction by which the futures leg may drift
+	// from the spot leg's quantity before Evaluate rebalances it.
+	driftTolerance float64
+}
+
+// NewStrategy creates a Strategy that opens a hedge when the funding rate
+// rises above fundingRateHigh and unwinds it once the rate decays below
+// fundingRateLow.
+func NewStrategy(spot, futures broker.Broker, funding *data.FundingRateFetcher, store Store, fundingRateHigh, fundingRateLow, leverage float64) *Strategy {
+	return &Strategy{
+		Spot:            spot,
+		Futures:         futures,
+		Funding:         funding,
+		Store:           store,
+		FundingRateHigh: fundingRateHigh,
+		FundingRateLow:  fundingRateLow,
+		Leverage:        leverage,
+		driftTolerance:  0.01,
+	}
+}
+
+// Evaluate polls the current funding rate for symbol and advances the hedge
+// state machine: opening a new hedge of the given quantity when funding
+// exceeds FundingRateHigh, rebalancing when the legs have drifted apart,
+// and unwinding once funding decays below FundingRateLow.
+func (s *Strategy) Evaluate(ctx context.Context, symbol string, quantity float64) error {
+	rate, err := s.Funding.FetchFundingRate(ctx, symbol)
+	if err != nil {
+		return err
+	}
+
+	pos, found, err := s.Store.Load(symbol)
+	if err != nil {
+		return err
+	}
+	if !found {
+		pos = &Position{Symbol: symbol, State: Idle}
+	}
+
+	var evalErr error
+	switch pos.State {
+	case Idle:
+		if rate.Rate > s.FundingRateHigh {
+			evalErr = s.open(symbol, quantity, pos)
+			if evalErr == nil {
+				log.Printf("xfunding: opened hedge for %s at funding rate %.5f", symbol, rate.Rate)
+			}
+		}
+
+	case Hedged:
+		if rate.Rate < s.FundingRateLow {
+			evalErr = s.unwind(symbol, pos)
+			if evalErr == nil {
+				pos.State = Idle
+				pos.SpotQty = 0
+				pos.FuturesQty = 0
+				log.Printf("xfunding: unwound hedge for %s as funding rate decayed to %.5f", symbol, rate.Rate)
+			}
+		} else if s.driftExceeded(pos) {
+			evalErr = s.rebalance(symbol, pos)
+		}
+	}
+
+	// Save whatever state open/unwind/rebalance reached even on error, so a
+	// leg that filled before a later leg failed isn't re-opened on the next
+	// Evaluate call.
+	if err := s.Store.Save(symbol, pos); err != nil {
+		return err
+	}
+	return evalErr
+}
+
+// open establishes the hedge: long spot, short the equivalent futures
+// quantity scaled by Leverage. pos is updated to Hedged as soon as the spot
+// leg fills, before the futures leg is attempted, so a failure placing the
+// futures leg leaves a persisted, drifted Hedged position (corrected by the
+// next rebalance) rather than an Idle position with a live spot leg that
+// would be opened again.
+func (s *Strategy) open(symbol string, quantity float64, pos *Position) error {
+	if _, err := s.Spot.PlaceOrder(symbol, quantity, alpaca.Buy, alpaca.Market, alpaca.GTC); err != nil {
+		return err
+	}
+	pos.State = Hedged
+	pos.SpotQty = quantity
+
+	futuresQty := quantity / math.Max(s.Leverage, 1)
+	if _, err := s.Futures.PlaceOrder(symbol, futuresQty, alpaca.Sell, alpaca.Market, alpaca.GTC); err != nil {
+		return err
+	}
+	pos.FuturesQty = futuresQty
+	return nil
+}
+
+// unwind closes both legs of an open hedge. Like open, it records each leg
+// closing (by zeroing its quantity) as soon as that leg fills, before
+// attempting the next, so a failure closing the futures leg doesn't leave a
+// persisted position that still thinks the already-closed spot leg is open
+// and resubmits a sell for it on the next unwind attempt.
+func (s *Strategy) unwind(symbol string, pos *Position) error {
+	if pos.SpotQty != 0 {
+		if _, err := s.Spot.PlaceOrder(symbol, pos.SpotQty, alpaca.Sell, alpaca.Market, alpaca.GTC); err != nil {
+			return err
+		}
+		pos.SpotQty = 0
+	}
+
+	if pos.FuturesQty != 0 {
+		if _, err := s.Futures.PlaceOrder(symbol, pos.FuturesQty, alpaca.Buy, alpaca.Market, alpaca.GTC); err != nil {
+			return err
+		}
+		pos.FuturesQty = 0
+	}
+
+	return nil
+}
+
+// driftExceeded reports whether the futures leg has drifted from the spot
+// leg's quantity by more than driftTolerance.
+func (s *Strategy) driftExceeded(pos *Position) bool {
+	if pos.SpotQty == 0 {
+		return false
+	}
+	drift := math.Abs(pos.SpotQty-pos.FuturesQty*s.Leverage) / pos.SpotQty
+	return drift > s.driftTolerance
+}
+
+// rebalance trims or adds to the futures leg so it tracks the spot leg's
+// quantity again.
+func (s *Strategy) rebalance(symbol string, pos *Position) error {
+	target := pos.SpotQty / math.Max(s.Leverage, 1)
+	delta := target - pos.FuturesQty
+	if delta == 0 {
+		return nil
+	}
+
+	side := alpaca.Sell
+	qty := delta
+	if delta < 0 {
+		side = alpaca.Buy
+		qty = -delta
+	}
+
+	if _, err := s.Futures.PlaceOrder(symbol, qty, side, alpaca.Market, alpaca.GTC); err != nil {
+		return err
+	}
+	pos.FuturesQty = target
+	log.Printf("xfunding: rebalanced futures leg for %s to %f", symbol, target)
+	return nil
+}
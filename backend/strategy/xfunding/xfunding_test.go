@@ -0,0 +1,111 @@
+package xfunding
+
+import (
+	"errors"
+	"testing"
+
+	"aionvanguard/backend/broker"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v2/alpaca"
+)
+
+// fakeBroker is a minimal broker.Broker whose PlaceOrder can be made to
+// fail, to exercise the case where one leg of a hedge fills and the other
+// doesn't.
+type fakeBroker struct {
+	placeOrderErr error
+	calls         int
+}
+
+var _ broker.Broker = (*fakeBroker)(nil)
+
+func (f *fakeBroker) PlaceOrder(symbol string, qty float64, side alpaca.Side, orderType alpaca.OrderType, timeInForce alpaca.TimeInForce) (string, error) {
+	f.calls++
+	if f.placeOrderErr != nil {
+		return "", f.placeOrderErr
+	}
+	return "order-1", nil
+}
+
+func (f *fakeBroker) PlaceBracketOrder(symbol string, qty float64, side alpaca.Side, entry, stop, target float64) (string, error) {
+	return "", nil
+}
+
+func (f *fakeBroker) GetAccount() (*alpaca.Account, error) { return &alpaca.Account{}, nil }
+
+func (f *fakeBroker) CancelOpenOrders(symbol string) error { return nil }
+
+func (f *fakeBroker) GetOpenPositions() ([]alpaca.Position, error) { return nil, nil }
+
+func (f *fakeBroker) GetClock() (*alpaca.Clock, error) { return &alpaca.Clock{}, nil }
+
+var errFuturesLegDown = errors.New("futures leg unavailable")
+
+// TestOpenPersistsSpotLegBeforeFuturesLegFails guards against the
+// double-open bug where a spot fill followed by a failed futures leg left
+// the position Idle, so the next Evaluate call re-opened the spot leg.
+func TestOpenPersistsSpotLegBeforeFuturesLegFails(t *testing.T) {
+	spot := &fakeBroker{}
+	futures := &fakeBroker{placeOrderErr: errFuturesLegDown}
+	store := NewInMemoryStore()
+	s := &Strategy{Spot: spot, Futures: futures, Store: store, Leverage: 1, driftTolerance: 0.01}
+
+	pos := &Position{Symbol: "BTCUSD", State: Idle}
+	if err := s.open("BTCUSD", 10, pos); err == nil {
+		t.Fatal("open: expected error from the failed futures leg")
+	}
+	if err := store.Save("BTCUSD", pos); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	if pos.State != Hedged {
+		t.Fatalf("State = %v, want Hedged (spot leg already filled, must not be re-opened)", pos.State)
+	}
+	if pos.SpotQty != 10 {
+		t.Fatalf("SpotQty = %f, want 10", pos.SpotQty)
+	}
+	if spot.calls != 1 {
+		t.Fatalf("spot.PlaceOrder called %d times, want exactly 1", spot.calls)
+	}
+
+	// The next reconciliation pass must see the drifted futures leg and
+	// rebalance it, rather than Evaluate's Idle branch re-opening the spot
+	// leg a second time.
+	if !s.driftExceeded(pos) {
+		t.Fatal("driftExceeded should report true so rebalance completes the missing futures leg")
+	}
+}
+
+// TestUnwindDoesNotResubmitAClosedSpotLeg guards against the mirror bug:
+// unwind's spot sell fills but the futures buy-to-cover fails, and a later
+// retry re-sells the spot leg because the position still thought it was
+// open.
+func TestUnwindDoesNotResubmitAClosedSpotLeg(t *testing.T) {
+	spot := &fakeBroker{}
+	futures := &fakeBroker{placeOrderErr: errFuturesLegDown}
+	s := &Strategy{Spot: spot, Futures: futures, Leverage: 1, driftTolerance: 0.01}
+
+	pos := &Position{Symbol: "BTCUSD", State: Hedged, SpotQty: 10, FuturesQty: 10}
+	if err := s.unwind("BTCUSD", pos); err == nil {
+		t.Fatal("unwind: expected error from the failed futures leg")
+	}
+	if pos.SpotQty != 0 {
+		t.Fatalf("SpotQty = %f, want 0 (spot leg already closed, must not be re-sold)", pos.SpotQty)
+	}
+	if spot.calls != 1 {
+		t.Fatalf("spot.PlaceOrder called %d times, want exactly 1", spot.calls)
+	}
+
+	// Retry with the futures leg now healthy: the spot leg must not be
+	// touched again.
+	futures.placeOrderErr = nil
+	if err := s.unwind("BTCUSD", pos); err != nil {
+		t.Fatalf("unwind retry: unexpected error: %v", err)
+	}
+	if spot.calls != 1 {
+		t.Fatalf("spot.PlaceOrder called %d times after retry, want still 1 (no resubmission)", spot.calls)
+	}
+	if pos.FuturesQty != 0 {
+		t.Fatalf("FuturesQty = %f, want 0 after the retry succeeds", pos.FuturesQty)
+	}
+}